@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// expandArgFiles replaces each arg in argv that begins with @ with the args in the file it names (e.g. @args.txt),
+// including args in the file that name other files, until a standalone "--".
+// An error is returned for each file that cannot be read or names itself.
+func expandArgFiles(argv []string) (expanded []string, errs []error) {
+	var terminated bool
+	var expand func(argv []string, files []string)
+	expand = func(argv []string, files []string) {
+		for _, a := range argv {
+			if terminated || a == "@" || !strings.HasPrefix(a, "@") {
+				terminated = terminated || a == "--"
+				expanded = append(expanded, a)
+				continue
+			}
+
+			var path = strings.TrimPrefix(a, "@")
+			if isExpanding(files, path) {
+				errs = append(errs, fmt.Errorf("%s includes itself", a))
+				continue
+			}
+			var contents, err = os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to read %s: %w", a, err))
+				continue
+			}
+			var args []string
+			if args, err = splitArgs(string(contents)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", a, err))
+				continue
+			}
+			expand(args, append(files, path))
+		}
+	}
+	expand(argv, nil)
+
+	return
+}
+
+// isExpanding reports whether path is one of the files being expanded.
+func isExpanding(files []string, path string) bool {
+	for _, file := range files {
+		if file == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitArgs splits the contents of an argument file into args separated by whitespace or newlines, like a shell.
+// Whitespace inside single or double quotes is part of the arg (e.g. --msg="hello world" or 'hello world').
+// Outside of quotes, a backslash escapes the next character. Inside double quotes, it only escapes " and \.
+// An error is returned if a quote is not closed.
+func splitArgs(contents string) (args []string, err error) {
+	var arg strings.Builder
+	var inArg, escaped bool
+	var quote rune
+	for _, c := range contents {
+		switch {
+		case escaped:
+			if quote == '"' && c != '"' && c != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case c == quote:
+			quote = 0
+		case (c == '"' || c == '\'') && quote == 0:
+			quote = c
+			inArg = true
+		case unicode.IsSpace(c) && quote == 0:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%c quote is not closed", quote)
+	}
+	if escaped {
+		arg.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return
+}
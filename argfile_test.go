@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArgFiles(t *testing.T) {
+	AllowArgFiles = true
+	t.Cleanup(func() {
+		AllowArgFiles = false
+	})
+
+	var dir = t.TempDir()
+	var nested = filepath.Join(dir, "nested.txt")
+	if err := os.WriteFile(nested, []byte("-v\n@"+nested+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var path = writeConfig(t, "args.txt", "--output file.txt\n--msg=\"hello world\" \"two words\"\n@"+nested+"\n-- @ignored.txt\n")
+
+	setArgs(t, "build", "@missing.txt", "@"+path, "last")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "msg", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	if Value("output") != "file.txt" || Value("msg") != "hello world" || !Using("verbose") {
+		t.Errorf("expected flags from argument files to be used, got %v", Args)
+	}
+	if positional := Positional(); strings.Join(positional, "|") != "build|two words|@ignored.txt|last" {
+		t.Errorf("expected positional args from argument files, got %q", positional)
+	}
+
+	var errs = ParseErrors()
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "unable to read @missing.txt") || errs[1].Error() != "@"+nested+" includes itself" {
+		t.Errorf("expected argument file errors, got %v", errs)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	var tests = []struct {
+		contents string
+		expected []string
+	}{
+		{"--msg=\"a b\" -v", []string{"--msg=a b", "-v"}},
+		{"'single quoted' 'it\\s'", []string{"single quoted", "it\\s"}},
+		{`--msg="say \"hi\"" --path="C:\dir"`, []string{`--msg=say "hi"`, `--path=C:\dir`}},
+		{`escaped\ space \'quote\'`, []string{"escaped space", "'quote'"}},
+		{"\"\" ''\n\tlast", []string{"", "", "last"}},
+	}
+	for _, test := range tests {
+		var args, err = splitArgs(test.contents)
+		if err != nil || fmt.Sprintf("%q", args) != fmt.Sprintf("%q", test.expected) {
+			t.Errorf("expected %s to be split into %q, got %q (%v)", test.contents, test.expected, args, err)
+		}
+	}
+
+	if _, err := splitArgs(`--msg="unclosed`); err == nil || err.Error() != "\" quote is not closed" {
+		t.Errorf("expected an unclosed quote error, got %v", err)
+	}
+}
@@ -5,203 +5,2401 @@
 package args
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 type Argument struct {
 	Name         string
 	Short        string
+	Shorts       []string
 	Description  string
 	DefaultValue string
+	DefaultFunc  func() string
 	Values       []string
 	ExpectsValue bool
+	Required     bool
+	Multiple     bool
+	EnvVar       string
+	Group        string
+	Deprecated   string
+	Aliases      []string
+	Negatable    bool
+	Type         Type
+	Separator    string
+	Hidden       bool
+	Greedy       bool
+	Validator    func(value string) error
 }
 
-// Args is a map of the args that were passed after the
+// Args is a map of the flags that were passed after the
 // first arg with dash prefixes (e.g. -- or -) trimmed.
+// Args that do not begin with a dash are positional and are not included.
 // A value is set for a member of Args if an arg is
-// proceeded with an equality operator (e.g. --arg=value).
+// proceeded with an equality operator (e.g. --arg=value),
+// or if a registered Argument that expects a value is
+// followed by an arg that does not begin with a dash (e.g. --arg value).
+// Args should not be read while args are being parsed by another goroutine.
 var Args map[string]string
 
 var registered []Argument
 
+// mutex guards registered arguments and parsed args, so that they can be read from multiple goroutines.
+// Arguments should still be registered and args parsed before they are read concurrently.
+var mutex sync.RWMutex
+
+// occurrences are the values of every occurrence of a passed arg in the order they were passed,
+// by the key of the Argument they refer to (its Name, or its Short if it does not have a Name).
+var occurrences map[string][]string
+
 // CustomUsage allows you to add custom usage details.
 // The value of CustomUsage is printed in between the
 // name of the binary and the flags in the usage message.
 var CustomUsage string
 
+// ProgramDescription is a summary of what your program does,
+// printed in the usage message after the name of the binary and the flags, wrapped to the width of the terminal.
+var ProgramDescription string
+
+// UsageHeader is printed in the usage message after the name of the binary and the flags, before the options.
+var UsageHeader string
+
+// UsageFooter is printed at the end of the usage message, after the options (e.g. a link to documentation).
+var UsageFooter string
+
+// UsageStrings are the labels printed in the usage message.
+type UsageStrings struct {
+	Usage      string
+	Options    string
+	Commands   string
+	Examples   string
+	Default    string
+	Aliases    string
+	Env        string
+	Required   string
+	Deprecated string
+}
+
+// Strings are the labels printed in the usage message, which can be replaced to translate it (e.g. Strings.Options = "Optionen").
+// Headings are followed by a colon (e.g. Options:), and the rest are printed in brackets after the description of an Argument
+// (e.g. [default=value] or [required]).
+var Strings = UsageStrings{
+	Usage:      "USAGE",
+	Options:    "Options",
+	Commands:   "Commands",
+	Examples:   "Examples",
+	Default:    "default",
+	Aliases:    "aliases",
+	Env:        "env",
+	Required:   "required",
+	Deprecated: "deprecated",
+}
+
+// ColorUsage prints the flags in the usage message in bold when it is written to a terminal,
+// unless the NO_COLOR environment variable is set.
+var ColorUsage bool
+
+// UsageWidth is the width that descriptions in the usage message are wrapped to.
+// If UsageWidth is 0, the width of the terminal is read from the COLUMNS environment variable,
+// and descriptions are not wrapped if it is not set.
+var UsageWidth int
+
+// SortUsage sorts the arguments in the usage message by Name,
+// instead of the order they were registered in.
+var SortUsage bool
+
+// AllowSlashFlags allows flags to be prefixed with a slash (e.g. /arg),
+// with a colon or an equality operator separating its value (e.g. /arg:value or /arg=value).
+// Set AllowSlashFlags before registering arguments, otherwise args that begin with a slash are positional.
+var AllowSlashFlags bool
+
+// Prefixes are the prefixes that flags begin with (e.g. --arg or -a), checked longest first.
+// An arg that begins with a Prefix that is only one character can be stacked shorthand arguments (e.g. -abc).
+// Set Prefixes before registering arguments.
+var Prefixes = []string{"--", "-"}
+
+// Terminators are args that end flag parsing like a standalone "--", such as a keyword that runs another executable
+// (e.g. exec for mytool exec npm --version). Unlike "--", a terminator is positional itself,
+// followed by every arg after it, so the args after it can be passed to the other executable untouched (see After).
+// A terminator is never the value of the flag before it. Set Terminators before registering arguments.
+var Terminators []string
+
+// WarnDeprecated prints a warning to stderr when a Deprecated Argument is used.
+// Set WarnDeprecated to false to collect the warnings with DeprecationWarnings instead.
+var WarnDeprecated = true
+
+// AllowEmptyValues allows an Argument that expects a value to be passed an empty value (e.g. --arg=).
+// Set AllowEmptyValues to false for Validate to return an error for empty values,
+// as it does for an Argument passed without a value (e.g. --arg).
+var AllowEmptyValues = true
+
+// TrimValues trims leading and trailing whitespace from every value (e.g. --name=" bob "),
+// whether it was passed, loaded from a config or .env file, or read from an EnvVar.
+// Set TrimValues before registering arguments.
+var TrimValues bool
+
+// AllowArgFiles allows args that begin with @ to be replaced with the args in the file they name (e.g. @args.txt).
+// Set AllowArgFiles before registering arguments.
+var AllowArgFiles bool
+
+// IgnoreMalformed skips malformed flags (e.g. --=value) without reporting them in ParseErrors.
+// Malformed flags are never included in Args.
+var IgnoreMalformed bool
+
+// AllowAbbrev allows flags to be abbreviated to a prefix of the Name of a registered Argument (e.g. --verb for --verbose),
+// as long as no other registered Argument begins with the same prefix.
+// Set AllowAbbrev before registering arguments.
+var AllowAbbrev bool
+
+// EnvPrefix allows every Argument without an EnvVar to fall back to an environment variable named by EnvPrefix
+// followed by its Name uppercased with dashes replaced by underscores (e.g. MYAPP_ and --max-retries for MYAPP_MAX_RETRIES).
+// An Argument with an EnvVar always uses its EnvVar instead.
+var EnvPrefix string
+
+// valueless are the keys of the arguments that expect a value but were last passed without one (e.g. --arg).
+var valueless map[string]bool
+
+// positional are the args passed that are not flags, in the order they were passed.
+var positional []string
+
+// firstPositional is the index in parsedArgs of the first positional arg, or -1 if there are none.
+var firstPositional = -1
+
+// parseErrors are the errors for malformed args, in the order they were passed.
+var parseErrors []error
+
+// rawArgs are the args being parsed, not including the name of the binary.
+var rawArgs []string
+
+// parsedArgs are rawArgs as they were parsed, with argument files expanded if AllowArgFiles is true.
+var parsedArgs []string
+
+// warned are the keys of the Deprecated arguments that a warning was printed for since args were last parsed.
+var warned = make(map[string]bool)
+
+// parsed is true if args were parsed by Parse, Reparse or Register since the package was initialized or Reset was called.
+var parsed bool
+
+// read are the keys of the arguments that were read, such as by Value or Using.
+var read = make(map[string]bool)
+
+// readMutex guards read, which is written by callers that only hold a read lock of mutex.
+var readMutex sync.Mutex
+
 func init() {
+	if len(os.Args) > 1 {
+		rawArgs = os.Args[1:]
+	}
+	parseArgs()
+}
+
+// Parse parses argv in place of the args passed to the executable.
+// argv should not include the name of the binary (e.g. os.Args[1:]).
+// The args passed to the executable are parsed when the package is initialized,
+// and they are re-parsed each time an Argument is registered, so Parse does not need to be called to parse them.
+func Parse(argv []string) {
+	mutex.Lock()
+	// Deprecation warnings are printed again only for different args, such as when the same args are parsed after registering arguments.
+	if !equalArgs(argv, rawArgs) {
+		warned = make(map[string]bool)
+	}
+	mutex.Unlock()
+
+	parse(argv)
+}
+
+// Reparse re-parses the args against the registered arguments, returning the ParseErrors as Errors, or nil if every arg is well-formed.
+// Register re-parses the args itself, so Reparse is only needed to check for malformed args after registering arguments
+// (e.g. if err := args.Reparse(); err != nil).
+func Reparse() error {
+	mutex.RLock()
+	var argv = rawArgs
+	mutex.RUnlock()
+
+	parse(argv)
+
+	var errs = ParseErrors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return Errors(errs)
+}
+
+// parse parses argv like Parse, without printing the deprecation warnings that were already printed.
+func parse(argv []string) {
+	mutex.Lock()
+	rawArgs = argv
 	parseArgs()
+	parsed = true
+	var args = append([]Argument(nil), registered...)
+	mutex.Unlock()
+
+	for _, r := range args {
+		warnDeprecated(r)
+	}
+}
+
+// equalArgs determines if a and b are the same args in the same order.
+func equalArgs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetArgs replaces the args being parsed with argv and parses them, leaving registered arguments intact.
+// It is the same as Parse, and can be used to parse the args after a subcommand
+// once the arguments of the subcommand are registered (e.g. SetArgs(os.Args[2:])).
+func SetArgs(argv []string) {
+	Parse(argv)
+}
+
+// Reset clears all registered arguments and parsed args.
+// Use Parse to parse a new list of args afterward.
+// CustomUsage is not cleared, examples added with Example are.
+func Reset() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	registered = nil
+	rawArgs = nil
+	parsedArgs = nil
+	Args = make(map[string]string)
+	occurrences = make(map[string][]string)
+	valueless = make(map[string]bool)
+	positional = []string{}
+	firstPositional = -1
+	parseErrors = nil
+	config = make(map[string]string)
+	dotenv = make(map[string]string)
+	commands = nil
+	dispatched = nil
+	examples = nil
+	exclusive = nil
+	requirements = nil
+
+	parsed = false
+	warned = make(map[string]bool)
+
+	readMutex.Lock()
+	read = make(map[string]bool)
+	readMutex.Unlock()
+}
+
+// Parsed reports whether args were parsed against the registered arguments by Parse, SetArgs, Reparse or Register,
+// rather than only the cheap parse of the args passed to the executable when the package is initialized.
+// Reset makes Parsed return false until args are parsed again.
+func Parsed() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return parsed
 }
 
-// parseArgs parses the arguments passed to the executable.
+// parseArgs parses rawArgs into Args.
 func parseArgs() {
 	Args = make(map[string]string)
-	if len(os.Args) <= 1 {
-		return
+	occurrences = make(map[string][]string)
+	valueless = make(map[string]bool)
+	positional = []string{}
+	firstPositional = -1
+	parseErrors = nil
+	var argv = rawArgs
+	if AllowArgFiles {
+		argv, parseErrors = expandArgFiles(rawArgs)
 	}
-	for i, a := range os.Args {
-		if i == 0 {
+	parsedArgs = argv
+	for i := 0; i < len(argv); i++ {
+		var a = argv[i]
+
+		// A standalone "--" ends flag parsing, every arg after it is positional.
+		if a == "--" {
+			if len(positional) == 0 && i+1 < len(argv) {
+				firstPositional = i + 1
+			}
+			positional = append(positional, argv[i+1:]...)
+			break
+		}
+		if isTerminator(a) {
+			if len(positional) == 0 {
+				firstPositional = i
+			}
+			positional = append(positional, argv[i:]...)
+			break
+		}
+
+		// An arg that does not begin with a prefix, or a standalone prefix (e.g. "-"), is positional.
+		var slash = AllowSlashFlags && strings.HasPrefix(a, "/") && a != "/"
+		var prefix = flagPrefix(a)
+		if !slash && (prefix == "" || a == prefix) {
+			if len(positional) == 0 {
+				firstPositional = i
+			}
+			positional = append(positional, a)
 			continue
 		}
-		if strings.Contains(a, "--") {
-			a = strings.TrimPrefix(a, "--")
-		} else if strings.Contains(a, "-") {
-			a = strings.TrimPrefix(a, "-")
+
+		if slash {
+			a = strings.TrimPrefix(a, "/")
+			if sep := strings.IndexAny(a, ":="); sep != -1 {
+				if sep == 0 {
+					missingFlagName(argv[i])
+					continue
+				}
+				setArg(a[:sep], a[sep+1:])
+				continue
+			}
+		} else {
+			a = strings.TrimPrefix(a, prefix)
+
+			// Stacked shorthand arguments (e.g. -abc) are split into each shorthand argument (e.g. -a -b -c).
+			if utf8.RuneCountInString(prefix) == 1 && len(a) > 1 {
+				if shorts, value, hasValue, ok := splitShorts(a); ok {
+					var last = len(shorts) - 1
+					for _, short := range shorts[:last] {
+						setArg(short, "")
+					}
+					if hasValue {
+						setArg(shorts[last], value)
+						if value != "" {
+							i = takeGreedy(argv, i, shorts[last])
+						}
+						continue
+					}
+					a = shorts[last]
+				}
+			}
 		}
+
 		if strings.Contains(a, "=") {
-			var keyValue = strings.Split(a, "=")
-			if len(keyValue) > 1 {
-				Args[keyValue[0]] = keyValue[1]
+			var keyValue = strings.SplitN(a, "=", 2)
+			if keyValue[0] == "" {
+				missingFlagName(argv[i])
 				continue
 			}
+			setArg(keyValue[0], keyValue[1])
+			if keyValue[1] != "" {
+				i = takeGreedy(argv, i, keyValue[0])
+			}
+			continue
 		}
-		Args[a] = ""
-	}
-}
 
-// PrintUsage writes a usage message to stderr based on the arguments and usage you have registered.
-func PrintUsage() {
-	var argumentsUsage = fmt.Sprintf("USAGE: %s %s [%s]\nOptions:\n", os.Args[0], CustomUsage, availableFlags())
-	var maxArgNameLen = argNameMaxLen()
-	for _, arg := range registered {
-		var short = arg.Short
-		var name = arg.Name
-		if arg.ExpectsValue {
-			short += "="
-			name += "="
-		} else {
-			short += " "
-			name += " "
+		// A flag that expects a value but was not given one with "=" takes the next arg as its value,
+		// unless the next arg begins with a prefix, in which case it is parsed as a flag of its own,
+		// or it is a standalone "-" or a negative number (e.g. --output - or --offset -5).
+		var value string
+		var arg, found = resolveArg(a)
+		if found && arg.ExpectsValue && i+1 < len(argv) {
+			if isValueArg(argv[i+1]) {
+				i++
+				value = argv[i]
+			} else {
+				parseErrors = append(parseErrors, fmt.Errorf("%s expects a value but was followed by %s", flagName(a), argv[i+1]))
+			}
+		}
+		setArg(a, value)
+		if found && arg.ExpectsValue && value == "" {
+			valueless[arg.key()] = true
 		}
 
-		var argumentUsage = "\t"
-		if arg.Short != "" {
-			argumentUsage += fmt.Sprintf(" -%s ", short)
-		} else {
-			argumentUsage += "    "
+		if value != "" {
+			i = takeGreedy(argv, i, a)
 		}
+	}
+}
 
-		argumentUsage += fmt.Sprintf("\t --%s ", name)
+// takeGreedy sets every arg after argv[i] as a value of key if it is a Greedy Argument,
+// until the next flag or a standalone "--", returning the index of the last arg it set.
+// The value of key in argv[i] is set by the caller, whether it was passed after "=" or as the next arg.
+func takeGreedy(argv []string, i int, key string) int {
+	if arg, found := resolveArg(key); !found || !arg.Greedy {
+		return i
+	}
+	for i+1 < len(argv) && argv[i+1] != "--" && isValueArg(argv[i+1]) {
+		i++
+		setArg(key, argv[i])
+	}
 
-		var argNameLength = len(arg.Name)
-		if argNameLength < maxArgNameLen {
-			argumentUsage += strings.Repeat(" ", maxArgNameLen-argNameLength)
-		}
+	return i
+}
 
-		argumentUsage += "\t"
+// missingFlagName records a parse error for arg that is missing a flag name (e.g. --=value), unless IgnoreMalformed is true.
+func missingFlagName(arg string) {
+	if !IgnoreMalformed {
+		parseErrors = append(parseErrors, fmt.Errorf("%s is missing a flag name", arg))
+	}
+}
 
-		if arg.Description != "" {
-			argumentUsage += fmt.Sprintf(" %s", arg.Description)
+// isTerminator determines if arg is one of Terminators.
+func isTerminator(arg string) bool {
+	for _, t := range Terminators {
+		if arg == t {
+			return true
 		}
+	}
 
-		if len(arg.Values) != 0 {
-			argumentUsage += " [" + strings.Join(arg.Values, ", ") + "]"
-		}
+	return false
+}
 
-		if arg.DefaultValue != "" {
-			argumentUsage += fmt.Sprintf(" [default=%s]", arg.DefaultValue)
+// flagPrefix returns the longest of Prefixes that arg begins with, or an empty string if it does not begin with one.
+func flagPrefix(arg string) (prefix string) {
+	for _, p := range Prefixes {
+		if strings.HasPrefix(arg, p) && len(p) > len(prefix) {
+			prefix = p
 		}
+	}
+
+	return
+}
 
-		argumentsUsage += argumentUsage + "\n"
+// isValueArg reports whether arg can be the value of the flag before it,
+// if it does not begin with a prefix, it is a standalone "-" that commonly means stdin or stdout (e.g. -o -),
+// or it is a negative number that is not a registered shorthand argument (e.g. -5).
+func isValueArg(arg string) bool {
+	if isTerminator(arg) {
+		return false
+	}
+	if arg == "-" {
+		return true
+	}
+	if flagPrefix(arg) == "" {
+		return true
+	}
+	if !isNegativeNumber(arg) {
+		return false
 	}
+	var _, found = resolveArg(strings.TrimLeft(arg, "-"))
 
-	var _, err = fmt.Fprint(os.Stderr, argumentsUsage)
-	if err != nil {
-		panic("unable to write to stderr")
+	return !found
+}
+
+// isNegativeNumber reports whether arg is a dash followed by a number (e.g. -5 or -.5),
+// so that words that parse as a float, such as -inf or -nan, are not values.
+func isNegativeNumber(arg string) bool {
+	var number = strings.TrimPrefix(arg, "-")
+	if number == arg || number == "" {
+		return false
+	}
+	if number[0] == '.' {
+		number = number[1:]
+	}
+	if number == "" || number[0] < '0' || number[0] > '9' {
+		return false
 	}
+	var _, err = strconv.ParseFloat(arg, 64)
+
+	return err == nil
 }
 
-// availableFlags generates the flags that could be used in a single line.
-func availableFlags() (flags string) {
-	for a, arg := range registered {
-		if arg.Short == "" {
-			flags += "--" + arg.Name
-		} else {
-			flags += "-" + arg.Short
-		}
-		if arg.ExpectsValue {
-			flags += "="
+// flagName returns key with the dash prefix it would be passed with (e.g. -a or --arg).
+func flagName(key string) string {
+	if utf8.RuneCountInString(key) == 1 {
+		return "-" + key
+	}
+
+	return "--" + key
+}
+
+// splitShorts splits stacked shorthand arguments (e.g. -abc) into each shorthand argument.
+// If one of the shorthand arguments expects a value, the rest of arg is its value (e.g. -ofile.txt),
+// and a value after "=" is the value of the last shorthand argument (e.g. -abc=1), hasValue is true if either was given.
+// A parse error is recorded if the last shorthand argument is given a value after "=" but does not expect one.
+// ok is false if arg is a registered argument or any of its shorthand arguments are not registered.
+func splitShorts(arg string) (shorts []string, value string, hasValue bool, ok bool) {
+	var cluster, assigned = arg, ""
+	var eq = strings.Index(arg, "=")
+	if eq != -1 {
+		cluster, assigned = arg[:eq], arg[eq+1:]
+	}
+	if _, found := lookupArg(cluster); found || utf8.RuneCountInString(cluster) < 2 {
+		return nil, "", false, false
+	}
+	for i, c := range cluster {
+		var r, found = lookupShort(string(c))
+		if !found {
+			return nil, "", false, false
 		}
-		if len(registered)-1 != a {
-			flags += " "
+		shorts = append(shorts, string(c))
+		if r.ExpectsValue {
+			var end = i + utf8.RuneLen(c)
+			if end == eq {
+				return shorts, assigned, true, true
+			}
+			return shorts, arg[end:], end != len(arg), true
 		}
 	}
+	if eq != -1 {
+		parseErrors = append(parseErrors, fmt.Errorf("-%s does not expect a value but was given \"%s\"", shorts[len(shorts)-1], assigned))
+	}
 
-	return
+	return shorts, "", false, true
 }
 
-// argNameMaxLen determines which registered argument has the longest argument name and returns its length.
-func argNameMaxLen() (max int) {
-	for _, arg := range registered {
-		var argNameLen = len(arg.Name)
-		if argNameLen < max {
-			continue
+// setArg sets the value of key in Args and records the value as an occurrence of the Argument that key refers to.
+func setArg(key string, value string) {
+	if matches := lookupAbbrev(key); len(matches) > 1 {
+		var names []string
+		for _, match := range matches {
+			names = append(names, "--"+match.Name)
 		}
+		parseErrors = append(parseErrors, fmt.Errorf("--%s is ambiguous, could be: %s", key, strings.Join(names, ", ")))
+		return
+	}
 
-		max = len(arg.Name)
+	value = trimValue(value)
+	Args[key] = value
+
+	var name = key
+	if arg, found := resolveArg(key); found {
+		name = arg.key()
+	} else if arg, found := lookupNegated(key); found {
+		name = arg.key()
+		value = "false"
+	}
+	occurrences[name] = append(occurrences[name], value)
+	delete(valueless, name)
+}
+
+// resolveArg returns the registered Argument that key refers to, by its Name, Short, one of its Shorts or one of its Aliases,
+// or if AllowAbbrev is true, by an abbreviation of its Name.
+func resolveArg(key string) (Argument, bool) {
+	if arg, found := lookupArg(key); found {
+		return arg, true
+	}
+	if matches := lookupAbbrev(key); len(matches) == 1 {
+		return matches[0], true
 	}
 
-	return max
+	return Argument{}, false
 }
 
-// Register an Argument.
-func Register(arg Argument) {
-	if arg.DefaultValue != "" && !arg.ExpectsValue {
-		panic(fmt.Sprintf("--%s has a default value but does not expect value", arg.Name))
+// lookupAbbrev returns every registered Argument with a Name that key is an abbreviation of, if AllowAbbrev is true.
+// key is not an abbreviation if it is a single character or refers to a registered Argument.
+func lookupAbbrev(key string) (matches []Argument) {
+	if !AllowAbbrev || utf8.RuneCountInString(key) < 2 {
+		return
+	}
+	if _, found := lookupArg(key); found {
+		return
 	}
 	for _, r := range registered {
-		if r.Name == arg.Name {
-			panic(fmt.Sprintf("--%s is already a registred argument", arg.Name))
-		}
-		if arg.Short != "" && r.Short == arg.Short {
-			panic(fmt.Sprintf("-%s is already a registred shorthand argument", arg.Short))
+		if strings.HasPrefix(r.Name, key) {
+			matches = append(matches, r)
 		}
 	}
-	registered = append(registered, arg)
+
+	return
 }
 
-// Using returns a boolean indicating if an Argument's Name was passed to your executable.
-// (e.g. --arg or -a)
-func Using(name string) bool {
-	if len(Args) == 0 {
-		return false
+// lookupNegated returns the registered Negatable Argument that key negates (e.g. no-arg).
+func lookupNegated(key string) (Argument, bool) {
+	if !strings.HasPrefix(key, "no-") {
+		return Argument{}, false
+	}
+	for _, r := range registered {
+		if r.Negatable && r.Name == strings.TrimPrefix(key, "no-") {
+			return r, true
+		}
 	}
 
-	if _, ok := Args[name]; ok {
-		return true
+	return Argument{}, false
+}
+
+// lookupArg returns the registered Argument with a Name, Short, one of its Shorts or one of its Aliases matching key.
+// An Argument with a Name matching key takes precedence.
+func lookupArg(key string) (Argument, bool) {
+	for _, r := range registered {
+		if r.Name != "" && r.Name == key {
+			return r, true
+		}
 	}
 	for _, r := range registered {
-		if r.Name != name {
-			continue
+		if r.hasKey(key) {
+			return r, true
 		}
-		if _, ok := Args[r.Short]; ok {
+	}
+	return Argument{}, false
+}
+
+// key returns the Name of arg, or its Short if arg does not have a Name.
+// The values of arg are stored by its key, however it was passed.
+func (arg Argument) key() string {
+	if arg.Name == "" {
+		return arg.Short
+	}
+
+	return arg.Name
+}
+
+// flag returns the flag of arg (e.g. --arg), or its shorthand flag if arg does not have a Name (e.g. -a).
+func (arg Argument) flag() string {
+	if arg.Name == "" {
+		return "-" + arg.Short
+	}
+
+	return "--" + arg.Name
+}
+
+// shorts returns the Short and Shorts of arg.
+func (arg Argument) shorts() []string {
+	if arg.Short == "" {
+		return arg.Shorts
+	}
+
+	return append([]string{arg.Short}, arg.Shorts...)
+}
+
+// hasShort determines if short is the Short or one of the Shorts of arg.
+func (arg Argument) hasShort(short string) bool {
+	for _, s := range arg.shorts() {
+		if s == short {
 			return true
 		}
 	}
+
 	return false
 }
 
-// Value returns a string value if an Argument's Name was passed to your executable with a value.
-// (e.g. --arg=value or -a=value)
-func Value(name string) string {
-	if len(Args) == 0 {
-		return ""
+// lookupRegistered is lookupArg for a caller that does not hold mutex.
+func lookupRegistered(key string) (Argument, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return lookupArg(key)
+}
+
+// keys returns the Name, Short, Shorts and Aliases of arg.
+func (arg Argument) keys() []string {
+	var keys []string
+	if arg.Name != "" {
+		keys = append(keys, arg.Name)
 	}
+	keys = append(keys, arg.shorts()...)
 
-	if val, ok := Args[name]; ok {
-		return val
+	return append(keys, arg.Aliases...)
+}
+
+// hasKey determines if key is the Name, Short, one of the Shorts or one of the Aliases of arg.
+func (arg Argument) hasKey(key string) bool {
+	for _, k := range arg.keys() {
+		if k == key {
+			return true
+		}
 	}
-	for _, r := range registered {
-		if r.Name != name {
+
+	return false
+}
+
+// PrintUsage writes a usage message to stderr based on the arguments and usage you have registered.
+func PrintUsage() {
+	if err := FprintUsage(os.Stderr); err != nil {
+		panic("unable to write to stderr")
+	}
+}
+
+// FprintUsage writes a usage message to w based on the arguments and usage you have registered.
+// If ColorUsage is true, flags are printed in bold when w is a terminal and NO_COLOR is not set.
+func FprintUsage(w io.Writer) error {
+	var _, err = fmt.Fprint(w, formatUsage(useColor(w)))
+	return err
+}
+
+// useColor reports whether the usage message written to w should be in color.
+func useColor(w io.Writer) bool {
+	if !ColorUsage || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	var file, ok = w.(*os.File)
+	if !ok {
+		return false
+	}
+	var info, err = file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// usage generates a usage message based on the arguments and usage you have registered.
+func usage() string {
+	return formatUsage(false)
+}
+
+// formatUsage generates a usage message, with the flags of each argument in bold if color is true.
+func formatUsage(color bool) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var argumentsUsage = fmt.Sprintf("%s: %s %s [%s]\n", Strings.Usage, programName(), CustomUsage, availableFlags())
+	if ProgramDescription != "" {
+		argumentsUsage += strings.Join(wrap(ProgramDescription, usageWidth()), "\n") + "\n\n"
+	}
+	if UsageHeader != "" {
+		argumentsUsage += UsageHeader + "\n\n"
+	}
+	argumentsUsage += commandsUsage()
+	argumentsUsage += optionsUsage(color)
+	argumentsUsage += examplesUsage()
+
+	if UsageFooter != "" {
+		argumentsUsage += "\n" + UsageFooter + "\n"
+	}
+
+	return argumentsUsage
+}
+
+// optionsUsage generates the list of arguments printed in the usage message under each heading,
+// with the flags of each argument in bold if color is true.
+func optionsUsage(color bool) (usage string) {
+	var args = usageArgs()
+	if len(args) == 0 {
+		usage += Strings.Options + ":\n"
+	}
+
+	var shortWidth int
+	for _, arg := range args {
+		if arg.Short == "" {
 			continue
 		}
-		if val, ok := Args[r.Short]; ok {
-			return val
+		if width := utf8.RuneCountInString(usageShort(arg)); width > shortWidth {
+			shortWidth = width
 		}
 	}
 
-	return ""
+	// Pad the flags of each argument to the longest flags so that descriptions are aligned.
+	var flags = make([]string, len(args))
+	var flagsWidth int
+	for i, arg := range args {
+		flags[i] = usageFlags(arg, shortWidth)
+		if utf8.RuneCountInString(flags[i]) > flagsWidth {
+			flagsWidth = utf8.RuneCountInString(flags[i])
+		}
+	}
+
+	for i, arg := range args {
+		if i == 0 || arg.Group != args[i-1].Group {
+			usage += usageHeading(arg.Group, i == 0)
+		}
+
+		var argumentUsage = "\t" + flags[i]
+		if color {
+			argumentUsage = "\t" + bold + flags[i] + reset
+		}
+
+		var details = usageDetails(arg)
+		if details != "" {
+			// Descriptions are wrapped to the width of the terminal, aligned after the flags.
+			var indent = flagsWidth + 2
+			var lines = wrap(details, usageWidth()-tabWidth-indent)
+			argumentUsage += strings.Repeat(" ", flagsWidth-utf8.RuneCountInString(flags[i])) + "  " +
+				strings.Join(lines, "\n\t"+strings.Repeat(" ", indent))
+		}
+
+		usage += argumentUsage + "\n"
+	}
+
+	return
+}
+
+// tabWidth is the width of the tab that each argument in the usage message is indented with.
+const tabWidth = 8
+
+// usageWidth returns UsageWidth, or if it is 0, the width of the terminal from the COLUMNS environment variable.
+// 0 is returned if the width cannot be determined.
+func usageWidth() int {
+	if UsageWidth != 0 {
+		return UsageWidth
+	}
+	var width, err = strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil {
+		return 0
+	}
+
+	return width
+}
+
+// wrap splits text into lines of words no longer than width, unless a word is longer than width.
+// If width is less than 1, text is not wrapped.
+func wrap(text string, width int) []string {
+	if width < 1 {
+		return []string{text}
+	}
+
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+
+	return append(lines, line)
+}
+
+// example is an example command printed in the usage message.
+type example struct {
+	command     string
+	description string
+}
+
+// examples are the examples added with Example, in the order they were added.
+var examples []example
+
+// Example adds an example command with a description to the usage message (e.g. Example("mytool build --release", "Build an optimized binary")).
+func Example(command string, description string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	examples = append(examples, example{command: command, description: description})
+}
+
+// examplesUsage generates the list of examples printed in the usage message.
+func examplesUsage() (usage string) {
+	if len(examples) == 0 {
+		return
+	}
+
+	var commandWidth int
+	for _, e := range examples {
+		if width := utf8.RuneCountInString(e.command); width > commandWidth {
+			commandWidth = width
+		}
+	}
+
+	usage = "\n" + Strings.Examples + ":\n"
+	for _, e := range examples {
+		var exampleUsage = "\t" + e.command
+		if e.description != "" {
+			exampleUsage += strings.Repeat(" ", commandWidth-utf8.RuneCountInString(e.command)) + "  # " + e.description
+		}
+		usage += exampleUsage + "\n"
+	}
+
+	return
+}
+
+// bold and reset are the ANSI escape codes to print the flags in the usage message in bold.
+const (
+	bold  = "\x1b[1m"
+	reset = "\x1b[0m"
+)
+
+// programName returns the name of the binary, or "program" if os.Args is empty.
+func programName() string {
+	if len(os.Args) == 0 {
+		return "program"
+	}
+
+	return os.Args[0]
+}
+
+// usageHeading generates the heading printed before the arguments in group.
+func usageHeading(group string, first bool) (heading string) {
+	if !first {
+		heading += "\n"
+	}
+	if group == "" {
+		return heading + Strings.Options + ":\n"
+	}
+
+	return heading + group + ":\n"
+}
+
+// usageShort generates the shorthand flag of arg as it is printed in the usage message (e.g. -a=).
+func usageShort(arg Argument) string {
+	if arg.Short == "" {
+		return ""
+	}
+	var short = "-" + arg.Short
+	if arg.ExpectsValue {
+		short += "="
+	}
+
+	return short
+}
+
+// usageFlags generates the flags of arg as they are printed in the usage message (e.g. -a= --arg=),
+// with the shorthand flag padded to shortWidth. Only the shorthand flag is generated if arg does not have a Name.
+func usageFlags(arg Argument, shortWidth int) (flags string) {
+	if arg.Name == "" {
+		flags = usageShort(arg)
+	} else {
+		if shortWidth != 0 {
+			var short = usageShort(arg)
+			flags += short + strings.Repeat(" ", shortWidth-utf8.RuneCountInString(short)) + "  "
+		}
+
+		flags += "--"
+		if arg.Negatable {
+			flags += "[no-]"
+		}
+		flags += arg.Name
+		if arg.ExpectsValue {
+			flags += "="
+		}
+	}
+	if arg.ExpectsValue && arg.Type != TypeString {
+		flags += "<" + arg.Type.String() + ">"
+	}
+
+	return
+}
+
+// usageDetails generates the description, values, default value, etc. of arg as they are printed in the usage message.
+func usageDetails(arg Argument) string {
+	var details []string
+	if arg.Description != "" {
+		details = append(details, arg.Description)
+	}
+
+	if len(arg.Values) != 0 {
+		details = append(details, "["+strings.Join(arg.Values, ", ")+"]")
+	}
+
+	if defaultValue := arg.defaultValue(); defaultValue != "" {
+		details = append(details, fmt.Sprintf("[%s=%s]", Strings.Default, defaultValue))
+	}
+
+	if len(arg.Shorts) != 0 || len(arg.Aliases) != 0 {
+		var aliases []string
+		for _, short := range arg.Shorts {
+			aliases = append(aliases, "-"+short)
+		}
+		for _, alias := range arg.Aliases {
+			if utf8.RuneCountInString(alias) == 1 {
+				aliases = append(aliases, "-"+alias)
+			} else {
+				aliases = append(aliases, "--"+alias)
+			}
+		}
+		details = append(details, "["+Strings.Aliases+"="+strings.Join(aliases, ", ")+"]")
+	}
+
+	if envVar := arg.envVar(); envVar != "" {
+		details = append(details, fmt.Sprintf("[%s=%s]", Strings.Env, envVar))
+	}
+
+	if arg.Required {
+		details = append(details, "["+Strings.Required+"]")
+	}
+
+	if arg.Deprecated != "" {
+		details = append(details, "["+Strings.Deprecated+"]")
+	}
+
+	return strings.Join(details, " ")
+}
+
+// availableFlags generates the flags that could be used in a single line.
+func availableFlags() string {
+	var flags []string
+	for _, arg := range usageArgs() {
+		var flag = "--" + arg.Name
+		if arg.Short != "" {
+			flag = "-" + arg.Short
+		}
+		if arg.ExpectsValue {
+			flag += "="
+		}
+		flags = append(flags, flag)
+	}
+
+	return strings.Join(flags, " ")
+}
+
+// usageArgs returns the registered arguments in the order they are printed in the usage message.
+// Arguments without a Group are first, followed by each Group sorted by name. Hidden arguments are not included.
+func usageArgs() []Argument {
+	var args []Argument
+	for _, r := range registered {
+		if !r.Hidden {
+			args = append(args, r)
+		}
+	}
+	if SortUsage {
+		sort.SliceStable(args, func(i, j int) bool {
+			return args[i].key() < args[j].key()
+		})
+	}
+	sort.SliceStable(args, func(i, j int) bool {
+		return args[i].Group < args[j].Group
+	})
+
+	return args
+}
+
+// Register an Argument.
+// Register panics if the Argument cannot be registered, use RegisterErr to handle the error instead.
+func Register(arg Argument) {
+	if err := RegisterErr(arg); err != nil {
+		panic(err.Error())
+	}
+}
+
+// RegisterErr registers an Argument, returning an error if the Argument cannot be registered.
+func RegisterErr(arg Argument) error {
+	mutex.Lock()
+	var err = register(arg)
+	mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	warnDeprecated(arg)
+
+	return nil
+}
+
+// RegisterAll registers each Argument in args in order, stopping at the first Argument that cannot be registered.
+// The error returned includes the index of that Argument in args.
+// The arguments before it remain registered.
+func RegisterAll(args ...Argument) error {
+	for i, arg := range args {
+		if err := RegisterErr(arg); err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// register registers arg and re-parses the args, the caller must hold mutex.
+func register(arg Argument) error {
+	if arg.Name == "" && arg.Short == "" {
+		return errors.New("argument does not have a name or shorthand")
+	}
+	if arg.Negatable && arg.Name == "" {
+		return fmt.Errorf("-%s is negatable but does not have a name", arg.Short)
+	}
+	if arg.DefaultValue != "" && arg.DefaultFunc != nil {
+		return fmt.Errorf("%s has both a default value and a default function", arg.flag())
+	}
+	if (arg.DefaultValue != "" || arg.DefaultFunc != nil) && !arg.ExpectsValue && !arg.Negatable {
+		return fmt.Errorf("%s has a default value but does not expect value", arg.flag())
+	}
+	if arg.Negatable && arg.ExpectsValue {
+		return fmt.Errorf("%s is negatable but expects value", arg.flag())
+	}
+	if arg.Short != "" && utf8.RuneCountInString(arg.Short) != 1 {
+		return fmt.Errorf("-%s is not a single character shorthand argument", arg.Short)
+	}
+	for _, short := range arg.Shorts {
+		if utf8.RuneCountInString(short) != 1 {
+			return fmt.Errorf("-%s is not a single character shorthand argument", short)
+		}
+	}
+	if arg.Multiple && !arg.ExpectsValue {
+		return fmt.Errorf("%s accepts multiple values but does not expect value", arg.flag())
+	}
+	if arg.Greedy && !arg.Multiple {
+		return fmt.Errorf("%s is greedy but does not accept multiple values", arg.flag())
+	}
+	for _, r := range registered {
+		if r.key() == arg.key() {
+			return fmt.Errorf("%s is already a registred argument", arg.flag())
+		}
+		// A Short is passed like a Name that is one character (e.g. -x), so they cannot be the same.
+		if arg.Name != "" && r.hasShort(arg.Name) {
+			return fmt.Errorf("-%s is already a registred shorthand argument", arg.Name)
+		}
+		for _, short := range arg.shorts() {
+			if r.hasShort(short) {
+				return fmt.Errorf("-%s is already a registred shorthand argument", short)
+			}
+			if r.Name == short {
+				return fmt.Errorf("-%s is already a registred argument", short)
+			}
+		}
+		for _, alias := range arg.Aliases {
+			if r.hasKey(alias) {
+				return fmt.Errorf("%s alias %s is already registered by %s", arg.flag(), alias, r.flag())
+			}
+		}
+		for _, alias := range r.Aliases {
+			if arg.hasKey(alias) {
+				return fmt.Errorf("%s is already a registered alias of %s", alias, r.flag())
+			}
+		}
+	}
+	registered = append(registered, arg)
+
+	// Re-parse so that arguments expecting a value can take the next arg as their value.
+	parseArgs()
+	parsed = true
+
+	return nil
+}
+
+// Unregister removes a registered Argument by its Name, Short or one of its Aliases,
+// returning false if no Argument was registered by name.
+func Unregister(name string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var arg, found = lookupArg(name)
+	if !found {
+		return false
+	}
+	for i, r := range registered {
+		if r.key() == arg.key() {
+			registered = append(registered[:i:i], registered[i+1:]...)
+			break
+		}
+	}
+
+	// Re-parse so that args that were the value of the Argument are parsed on their own.
+	parseArgs()
+
+	return true
+}
+
+// SetDefault sets the DefaultValue of a registered Argument by its Name, Short or one of its Aliases,
+// such as to override a default of an Argument registered by shared code. The DefaultFunc of the Argument is removed.
+// SetDefault returns an error if no Argument was registered by name, or the Argument does not expect a value and is not Negatable.
+func SetDefault(name string, value string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var arg, found = lookupArg(name)
+	if !found {
+		return fmt.Errorf("%s is not a registered argument", flagName(name))
+	}
+	if !arg.ExpectsValue && !arg.Negatable {
+		return fmt.Errorf("%s does not expect value", arg.flag())
+	}
+	for i, r := range registered {
+		if r.key() == arg.key() {
+			registered[i].DefaultValue = value
+			registered[i].DefaultFunc = nil
+			break
+		}
+	}
+
+	return nil
+}
+
+// warnDeprecated prints a warning to stderr if arg is Deprecated and was passed, unless WarnDeprecated is false.
+// The warning is printed once for the args that were parsed, however many times they are re-parsed.
+func warnDeprecated(arg Argument) {
+	if !WarnDeprecated || arg.Deprecated == "" {
+		return
+	}
+	mutex.Lock()
+	var _, set = lookup(arg.key())
+	var warn = set && !warned[arg.key()]
+	if warn {
+		warned[arg.key()] = true
+	}
+	mutex.Unlock()
+	if warn {
+		fmt.Fprintln(os.Stderr, deprecationWarning(arg))
+	}
+}
+
+// DeprecationWarnings returns a warning for each Deprecated Argument that was passed, in the order they were registered.
+// (e.g. --old is deprecated: use --new instead)
+func DeprecationWarnings() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var warnings = []string{}
+	for _, r := range registered {
+		if r.Deprecated == "" {
+			continue
+		}
+		if _, set := lookup(r.key()); set {
+			warnings = append(warnings, deprecationWarning(r))
+		}
+	}
+
+	return warnings
+}
+
+// deprecationWarning generates the warning for a Deprecated Argument.
+func deprecationWarning(arg Argument) string {
+	return fmt.Sprintf("%s is deprecated: %s", arg.flag(), arg.Deprecated)
+}
+
+// Registered returns a snapshot of the registered arguments in the order they were registered.
+// Changing the returned arguments does not change the registered arguments.
+func Registered() []Argument {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var args = make([]Argument, len(registered))
+	for i, r := range registered {
+		r.Values = append([]string(nil), r.Values...)
+		r.Shorts = append([]string(nil), r.Shorts...)
+		r.Aliases = append([]string(nil), r.Aliases...)
+		args[i] = r
+	}
+
+	return args
+}
+
+// ExpectsValue reports whether the registered Argument with a Name, Short or alias of name expects a value,
+// and whether an Argument was registered by name.
+func ExpectsValue(name string) (expectsValue bool, found bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var arg Argument
+	arg, found = lookupArg(name)
+
+	return arg.ExpectsValue, found
+}
+
+// VisitAll calls fn for each registered Argument in the order they were registered.
+func VisitAll(fn func(Argument)) {
+	for _, r := range Registered() {
+		fn(r)
+	}
+}
+
+// Visit calls fn for each registered Argument that was passed, in the order they were registered, with its value.
+// The value is resolved the same way as Lookup, by the Name or Short of the Argument, however the Argument was passed
+// (e.g. by its Short or one of its Aliases).
+func Visit(fn func(arg Argument, value string)) {
+	var visited []Argument
+	var values []string
+	mutex.RLock()
+	for _, r := range registered {
+		if value, set := lookup(r.key()); set {
+			visited = append(visited, r)
+			values = append(values, value)
+		}
+	}
+	mutex.RUnlock()
+
+	// fn is called without holding mutex, so that it can read arguments.
+	for i, r := range visited {
+		fn(r, values[i])
+	}
+}
+
+// Argv returns the registered arguments with the given names that were passed, or every one if no names are given,
+// as args that can be passed to another executable (e.g. with exec.Command).
+// Arguments that expect a value are returned as --name=value, once for each value of a Multiple Argument.
+// Arguments that do not expect a value are returned as --name, or --no-name if a Negatable Argument was negated.
+func Argv(names ...string) []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var args = registered
+	if len(names) != 0 {
+		args = nil
+		for _, name := range names {
+			if arg, found := lookupArg(name); found {
+				args = append(args, arg)
+			}
+		}
+	}
+
+	var argv = []string{}
+	for _, arg := range args {
+		var values = occurrences[arg.key()]
+		if len(values) == 0 {
+			continue
+		}
+		if !arg.Multiple {
+			values = values[len(values)-1:]
+		}
+		for _, value := range values {
+			argv = append(argv, argvFlag(arg, value))
+		}
+	}
+
+	return argv
+}
+
+// argvFlag returns arg passed with value as a single arg.
+func argvFlag(arg Argument, value string) string {
+	if arg.Negatable && isFalsy(value) {
+		return "--no-" + arg.Name
+	}
+	if !arg.ExpectsValue && value == "" {
+		return arg.flag()
+	}
+
+	return arg.flag() + "=" + value
+}
+
+// EnableHelp registers a --help argument, with a -h shorthand unless -h is already registered as a shorthand or an alias.
+// Use HandleHelp to print usage information if it was passed.
+func EnableHelp() {
+	if _, found := lookupRegistered("help"); found {
+		return
+	}
+
+	var help = Argument{
+		Name:        "help",
+		Short:       "h",
+		Description: "Print usage information",
+	}
+	if _, taken := lookupRegistered(help.Short); taken {
+		help.Short = ""
+	}
+	Register(help)
+}
+
+// HandleHelp prints usage information and returns true if --help or -h was passed,
+// so that you can exit your executable.
+func HandleHelp() bool {
+	if !Using("help") {
+		return false
+	}
+
+	PrintUsage()
+
+	return true
+}
+
+// version is the version string printed by HandleVersion.
+var version string
+
+// EnableVersion registers a --version argument, with a -v shorthand unless -v is already registered as a shorthand or an alias.
+// Use HandleVersion to print v if it was passed.
+func EnableVersion(v string) {
+	version = v
+	if _, found := lookupRegistered("version"); found {
+		return
+	}
+
+	var versionArg = Argument{
+		Name:        "version",
+		Short:       "v",
+		Description: "Print version",
+	}
+	if _, taken := lookupRegistered(versionArg.Short); taken {
+		versionArg.Short = ""
+	}
+	Register(versionArg)
+}
+
+// HandleVersion prints the version string passed to EnableVersion to stdout
+// and returns true if --version or -v was passed, so that you can exit your executable.
+func HandleVersion() bool {
+	if !Using("version") {
+		return false
+	}
+
+	fmt.Println(version)
+
+	return true
+}
+
+// lookupShort returns the registered Argument with a Short or one of its Shorts matching short.
+func lookupShort(short string) (Argument, bool) {
+	for _, r := range registered {
+		if r.hasShort(short) {
+			return r, true
+		}
+	}
+
+	return Argument{}, false
+}
+
+// UnknownFlags returns every flag in Args that is not the Name or Short of a registered Argument,
+// with dash prefixes trimmed and sorted alphabetically.
+func UnknownFlags() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return unknownFlags()
+}
+
+// unknownFlags is UnknownFlags for a caller that holds mutex.
+func unknownFlags() []string {
+	var unknown = []string{}
+	for key := range Args {
+		if _, found := resolveArg(key); found {
+			continue
+		}
+		if _, found := lookupNegated(key); found {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// Exit is called to exit with a status code, such as by DefaultOnMissing and when ExitOnError is true.
+// Exit is os.Exit, and is meant to be replaced only in tests.
+var Exit = os.Exit
+
+// StrictDuplicates makes Validate return an error for an Argument that expects a value and is not Multiple
+// but was passed more than once (e.g. --output=a --output=b), instead of using the last value.
+var StrictDuplicates bool
+
+// ExitOnError prints the error and a usage message to stderr and exits with a status of 2
+// when Validate would return an error, or there are ParseErrors or UnknownFlags.
+// This includes missing required arguments, OnMissing is not called if ExitOnError is true.
+var ExitOnError bool
+
+// OnMissing is called by Validate with the flags of every required Argument that is missing (e.g. --arg).
+// By default, OnMissing is DefaultOnMissing. If OnMissing returns, Validate returns an error listing the missing flags.
+// OnMissing is not called if ExitOnError is true.
+var OnMissing = DefaultOnMissing
+
+// DefaultOnMissing prints the missing flags and a usage message to stderr and exits with a status of 1.
+func DefaultOnMissing(missing []string) {
+	fmt.Fprintln(os.Stderr, missingError(missing))
+	PrintUsage()
+	Exit(1)
+}
+
+// exclusive are the groups of arguments added with MutuallyExclusive.
+var exclusive [][]string
+
+// MutuallyExclusive adds a group of arguments by their Name, Short or one of their Aliases that cannot be used together
+// (e.g. MutuallyExclusive("json", "yaml")). Validate returns an error if more than one of them was passed.
+func MutuallyExclusive(names ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	exclusive = append(exclusive, names)
+}
+
+// requirement is an Argument that requires other arguments, added with Requires.
+type requirement struct {
+	name         string
+	dependencies []string
+}
+
+// requirements are the requirements added with Requires.
+var requirements []requirement
+
+// Requires adds a requirement that if an Argument is passed, each of dependsOn is also passed, by their Name, Short or one of their Aliases
+// (e.g. Requires("tls-cert", "tls-key")). Validate returns an error if a dependency is not used,
+// so a dependency can also be set by a loaded config, its EnvVar, or a loaded .env file like Using.
+// Dependencies are not transitive, each requirement is checked on its own.
+func Requires(name string, dependsOn ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	requirements = append(requirements, requirement{name: name, dependencies: dependsOn})
+}
+
+// argFlag returns the flag of the registered Argument that name refers to (e.g. --arg), or the flag of name if it is not registered.
+func argFlag(name string) string {
+	if arg, found := lookupArg(name); found {
+		return arg.flag()
+	}
+
+	return flagName(name)
+}
+
+// Validate returns Errors listing every problem with the args passed for the registered arguments:
+// required arguments that were not passed and do not have a DefaultValue, MutuallyExclusive arguments passed together,
+// arguments passed without an Argument they Requires, values that are not one of the Values of their Argument,
+// arguments that expect a value but were passed without one and do not have a DefaultValue,
+// values that do not parse as the Type of their Argument, and values that the Validator of their Argument returns an error for.
+// If StrictDuplicates is true, arguments that expect a value and are not Multiple but were passed more than once are included.
+// The DefaultValue of an Argument that was not passed is checked against its Values, Type and Validator like a passed value.
+// Errors are sorted by the flag they are for.
+// If any required Argument is missing, OnMissing is called first, unless ExitOnError is true.
+// If ExitOnError is true, Validate exits instead of returning an error, including for ParseErrors and UnknownFlags.
+func Validate() error {
+	var err = validate()
+	if ExitOnError {
+		if err == nil {
+			err = parseError()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			PrintUsage()
+			Exit(2)
+		}
+	}
+
+	return err
+}
+
+// parseError returns the first of ParseErrors, or an error listing the UnknownFlags.
+func parseError() error {
+	if errs := ParseErrors(); len(errs) != 0 {
+		return errs[0]
+	}
+	var unknown = UnknownFlags()
+	if len(unknown) == 0 {
+		return nil
+	}
+	for i, key := range unknown {
+		unknown[i] = flagName(key)
+	}
+
+	return fmt.Errorf("unknown flags: %s", strings.Join(unknown, ", "))
+}
+
+// validate returns the error returned by Validate.
+func validate() error {
+	var missing []string
+	mutex.RLock()
+	for _, r := range registered {
+		if !r.Required || r.defaultValue() != "" || using(r.key()) {
+			continue
+		}
+		missing = append(missing, r.flag())
+	}
+	mutex.RUnlock()
+	if len(missing) != 0 {
+		// With ExitOnError, missing arguments exit with a status of 2 like every other error, instead of calling OnMissing.
+		if OnMissing != nil && !ExitOnError {
+			OnMissing(missing)
+		}
+	}
+
+	// Every error is sorted by the flag it is for, including an error for each missing argument.
+	var flagErrs []flagError
+	for _, flag := range missing {
+		flagErrs = append(flagErrs, flagError{flag, fmt.Errorf("missing required argument: %s", flag)})
+	}
+	mutex.RLock()
+	for _, names := range exclusive {
+		var passed []string
+		for _, name := range names {
+			if _, set := lookup(name); set {
+				passed = append(passed, argFlag(name))
+			}
+		}
+		if len(passed) > 1 {
+			flagErrs = append(flagErrs, flagError{passed[0], fmt.Errorf("cannot be used together: %s", strings.Join(passed, ", "))})
+		}
+	}
+
+	for _, r := range requirements {
+		if _, set := lookup(r.name); !set {
+			continue
+		}
+		for _, dependency := range r.dependencies {
+			if !using(dependency) {
+				flagErrs = append(flagErrs, flagError{argFlag(r.name), fmt.Errorf("%s requires %s", argFlag(r.name), argFlag(dependency))})
+			}
+		}
+	}
+	mutex.RUnlock()
+
+	for _, r := range Registered() {
+		for _, err := range checkArg(r) {
+			flagErrs = append(flagErrs, flagError{r.flag(), err})
+		}
+	}
+
+	sort.SliceStable(flagErrs, func(i, j int) bool {
+		return flagErrs[i].flag < flagErrs[j].flag
+	})
+	var errs Errors
+	for _, flagErr := range flagErrs {
+		errs = append(errs, flagErr.err)
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// flagError is an error returned by Validate for a flag.
+type flagError struct {
+	flag string
+	err  error
+}
+
+// checkArg returns the errors for the value of arg that Validate returns.
+// Checking arg does not count as reading it (see UnusedFlags).
+func checkArg(arg Argument) (errs []error) {
+	mutex.RLock()
+	var used, value, count = using(arg.key()), valueOf(arg.key()), len(occurrences[arg.key()])
+	// Every value passed to a Multiple Argument is checked, not only the last one.
+	var values = []string{value}
+	if arg.Multiple && count != 0 {
+		values = append([]string(nil), occurrences[arg.key()]...)
+	}
+	mutex.RUnlock()
+
+	// An Argument that was not passed is only checked if it has a default value, which is checked like a passed value.
+	if !used && value == "" {
+		return
+	}
+
+	if StrictDuplicates && arg.ExpectsValue && !arg.Multiple && count > 1 {
+		errs = append(errs, fmt.Errorf("%s was passed more than once", arg.flag()))
+	}
+	if invalid := invalidValues(arg, values); len(invalid) != 0 {
+		for _, v := range invalid {
+			errs = append(errs, fmt.Errorf("%s has invalid value \"%s\", expected one of: %s", arg.flag(), v, strings.Join(arg.Values, ", ")))
+		}
+	} else if err := checkValue(arg); err != nil {
+		errs = append(errs, err)
+	} else if typeErrs := checkTypes(arg, values); len(typeErrs) != 0 {
+		errs = append(errs, typeErrs...)
+	} else {
+		errs = append(errs, checkValidators(arg, values)...)
+	}
+
+	return
+}
+
+// checkValidators returns an error for each of values of arg that its Validator returns an error for.
+func checkValidators(arg Argument, values []string) (errs []error) {
+	for _, value := range values {
+		if err := checkValidator(arg, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
+
+// checkValidator returns an error if the Validator of arg returns an error for value.
+func checkValidator(arg Argument, value string) error {
+	if arg.Validator == nil {
+		return nil
+	}
+	if err := arg.Validator(value); err != nil {
+		return fmt.Errorf("%s: %w", arg.flag(), err)
+	}
+
+	return nil
+}
+
+// Errors are multiple errors returned as one error, such as by Validate.
+type Errors []error
+
+// Error returns the message of each error on its own line.
+func (errs Errors) Error() string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors, so that errors.Is and errors.As check each of them from Go 1.20.
+// With earlier versions of Go, range over Errors to check each error instead.
+func (errs Errors) Unwrap() []error {
+	return errs
+}
+
+// missingError generates the error returned by Validate for missing flags.
+func missingError(missing []string) error {
+	return fmt.Errorf("missing required arguments: %s", strings.Join(missing, ", "))
+}
+
+// checkValue returns an error if arg expects a value but was passed without one (e.g. --arg),
+// or with an empty value (e.g. --arg=) if AllowEmptyValues is false, and does not have a DefaultValue.
+func checkValue(arg Argument) error {
+	if !arg.ExpectsValue || arg.defaultValue() != "" {
+		return nil
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if value, set := lookup(arg.key()); valueless[arg.key()] || (set && value == "" && !AllowEmptyValues) {
+		return fmt.Errorf("%s requires a value", arg.flag())
+	}
+
+	return nil
+}
+
+// invalidValues returns each of values that is not empty and is not one of the Values of arg, if arg has Values.
+func invalidValues(arg Argument, values []string) (invalid []string) {
+	if len(arg.Values) == 0 {
+		return
+	}
+	for _, value := range values {
+		if value != "" && !isValue(arg, value) {
+			invalid = append(invalid, value)
+		}
+	}
+
+	return
+}
+
+// isValue determines if value is one of the Values of arg.
+func isValue(arg Argument, value string) bool {
+	for _, v := range arg.Values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Using returns a boolean indicating if an Argument was passed to your executable (e.g. --arg or -a),
+// by its Name, Short or one of its Aliases.
+// If the Argument was not passed, Using reports whether it has a value from a loaded config
+// or its EnvVar is set to a non-empty value.
+func Using(name string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	return using(name)
+}
+
+// using is Using for a caller that holds mutex.
+func using(name string) bool {
+	if _, set := lookup(name); set {
+		return true
+	}
+
+	var _, ok = fallbackValue(name)
+	return ok
+}
+
+// HasAny reports whether any of the arguments with the given names are used, the same as Using.
+// HasAny returns false if no names are given.
+func HasAny(names ...string) bool {
+	for _, name := range names {
+		if Using(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAll reports whether all of the arguments with the given names are used, the same as Using.
+// HasAll returns true if no names are given.
+func HasAll(names ...string) bool {
+	for _, name := range names {
+		if !Using(name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Value returns a string value if an Argument's Name was passed to your executable with a value.
+// (e.g. --arg=value, -a=value or --arg value)
+// If the Argument was not passed, its value from a loaded config is returned,
+// otherwise the value of its EnvVar, otherwise its DefaultValue or the value returned by its DefaultFunc.
+// DefaultFunc is called each time the default is needed while the arguments are locked, so it must not read other arguments.
+// An Argument passed with an empty value (e.g. --arg=) returns an empty string.
+func Value(name string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	return valueOf(name)
+}
+
+// valueOf is Value for a caller that holds mutex.
+func valueOf(name string) string {
+	if val, set := lookup(name); set {
+		return val
+	}
+	if val, ok := fallbackValue(name); ok {
+		return val
+	}
+	if arg, found := lookupArg(name); found {
+		return arg.defaultValue()
+	}
+
+	return ""
+}
+
+// defaultValue returns the value returned by the DefaultFunc of arg if it has one, otherwise its DefaultValue.
+func (arg Argument) defaultValue() string {
+	if arg.DefaultFunc != nil {
+		return arg.DefaultFunc()
+	}
+
+	return arg.DefaultValue
+}
+
+// ParseErrors returns an error for each malformed arg that was passed, in the order they were passed.
+// (e.g. --=value is missing a flag name)
+// Malformed args are not included in Args.
+func ParseErrors() []error {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var errs = make([]error, len(parseErrors))
+	copy(errs, parseErrors)
+
+	return errs
+}
+
+// Positional returns the args passed that are not flags in the order they were passed.
+// This includes args that do not begin with a dash, a standalone "-", and every arg after a standalone "--".
+func Positional() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var args = make([]string, len(positional))
+	copy(args, positional)
+
+	return args
+}
+
+// NumFlags returns the number of flags passed, the same as the number of members of Args.
+func NumFlags() int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return len(Args)
+}
+
+// NumPositional returns the number of positional args passed, the same as the number of args returned by Positional.
+func NumPositional() int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return len(positional)
+}
+
+// After returns the args passed after the first occurrence of token, exactly as they were passed (e.g. After("--")).
+// The token itself is not included. An empty slice is returned if token was not passed.
+func After(token string) []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for i, a := range parsedArgs {
+		if a == token {
+			return append([]string{}, parsedArgs[i+1:]...)
+		}
+	}
+
+	return []string{}
+}
+
+// Lookup returns the value of an Argument and a boolean indicating if it was actually passed to your executable,
+// by its Name, Short or one of its Aliases. Unlike Value, Lookup does not fall back to an Argument's EnvVar or DefaultValue.
+func Lookup(name string) (value string, set bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	return lookup(name)
+}
+
+// lookup is Lookup for a caller that holds mutex.
+func lookup(name string) (value string, set bool) {
+	if arg, found := lookupArg(name); found {
+		// The last occurrence of an Argument takes precedence, however it was passed.
+		var values = occurrences[arg.key()]
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[len(values)-1], true
+	}
+
+	var val, ok = Args[name]
+	return val, ok
+}
+
+// fallbackValue returns the value of an Argument that was not passed from a loaded config, its EnvVar, or a loaded .env file.
+// If TrimValues is true, whitespace around the value is trimmed.
+func fallbackValue(name string) (string, bool) {
+	var arg, found = lookupArg(name)
+	if !found {
+		return "", false
+	}
+	if val, ok := config[arg.key()]; ok {
+		return trimValue(val), true
+	}
+	if envVar := arg.envVar(); envVar != "" {
+		if val := trimValue(os.Getenv(envVar)); val != "" {
+			return val, true
+		}
+	}
+	if val, ok := dotenv[arg.key()]; ok {
+		return trimValue(val), true
+	}
+
+	return "", false
+}
+
+// trimValue returns value with leading and trailing whitespace trimmed if TrimValues is true.
+func trimValue(value string) string {
+	if TrimValues {
+		return strings.TrimSpace(value)
+	}
+
+	return value
+}
+
+// envVar returns the EnvVar of arg, or if it does not have one, has a Name and EnvPrefix is set,
+// EnvPrefix followed by its Name uppercased with dashes replaced by underscores (e.g. MYAPP_MAX_RETRIES).
+func (arg Argument) envVar() string {
+	if arg.EnvVar != "" || arg.Name == "" || EnvPrefix == "" {
+		return arg.EnvVar
+	}
+
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(arg.Name, "-", "_"))
+}
+
+// Int returns the value of an Argument parsed as an int.
+// If the Argument was not passed, its DefaultValue is parsed instead.
+// If the value is empty (e.g. --arg=), Int returns 0 and a nil error.
+func Int(name string) (int, error) {
+	return parseInt(name, Value(name))
+}
+
+// parseInt parses value of the Argument name as an int, the same as Int.
+func parseInt(name string, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var i, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s expects an integer value: %w", flagName(name), err)
+	}
+
+	return i, nil
+}
+
+// Float returns the value of an Argument parsed as a float64.
+// If the Argument was not passed, its DefaultValue is parsed instead.
+// If the value is empty (e.g. --arg=), Float returns 0 and a nil error.
+func Float(name string) (float64, error) {
+	return parseFloat(name, Value(name))
+}
+
+// parseFloat parses value of the Argument name as a float64, the same as Float.
+func parseFloat(name string, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var f, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s expects a number value, got \"%s\": %w", flagName(name), value, err)
+	}
+
+	return f, nil
+}
+
+// Duration returns the value of an Argument parsed as a time.Duration (e.g. 30s, 5m or 1h30m).
+// If the Argument was not passed, its DefaultValue is parsed instead.
+// If the value is empty (e.g. --arg=), Duration returns 0 and a nil error.
+func Duration(name string) (time.Duration, error) {
+	return parseDuration(name, Value(name))
+}
+
+// parseDuration parses value of the Argument name as a time.Duration, the same as Duration.
+func parseDuration(name string, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var d, err = time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s expects a duration value with a unit (e.g. 30s, 5m or 1h30m), got \"%s\": %w", flagName(name), value, err)
+	}
+
+	return d, nil
+}
+
+// Bool returns true if an Argument was passed without a value,
+// or with a value of true, 1, yes or on (case-insensitive).
+// If the Argument was not passed, its DefaultValue is parsed instead.
+func Bool(name string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	return boolOf(name)
+}
+
+// boolOf is Bool for a caller that holds mutex.
+func boolOf(name string) bool {
+	if using(name) {
+		var value = valueOf(name)
+		return value == "" || isTruthy(value)
+	}
+	if arg, found := lookupArg(name); found {
+		return isTruthy(arg.defaultValue())
+	}
+
+	return false
+}
+
+// isTruthy determines if value is one of the accepted truthy strings.
+func isTruthy(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "on":
+		return true
+	}
+
+	return false
+}
+
+// isFalsy determines if value is one of the accepted falsy strings.
+func isFalsy(value string) bool {
+	switch strings.ToLower(value) {
+	case "false", "0", "no", "off":
+		return true
+	}
+
+	return false
+}
+
+// StringDefault returns the value of an Argument like Value, or def if the value is empty.
+func StringDefault(name string, def string) string {
+	if value := Value(name); value != "" {
+		return value
+	}
+
+	return def
+}
+
+// IntDefault returns the value of an Argument parsed as an int like Int,
+// or def if the value is empty or is not an integer.
+func IntDefault(name string, def int) int {
+	if i, err := Int(name); err == nil && Value(name) != "" {
+		return i
+	}
+
+	return def
+}
+
+// FloatDefault returns the value of an Argument parsed as a float64 like Float,
+// or def if the value is empty or is not a number.
+func FloatDefault(name string, def float64) float64 {
+	if f, err := Float(name); err == nil && Value(name) != "" {
+		return f
+	}
+
+	return def
+}
+
+// BoolDefault returns the value of an Argument like Bool,
+// or def if the Argument was not passed and does not have a DefaultValue,
+// or its value is not one of true, 1, yes, on, false, 0, no or off (case-insensitive).
+func BoolDefault(name string, def bool) bool {
+	var value = Value(name)
+	if !Using(name) && value == "" {
+		return def
+	}
+	if value != "" && !isTruthy(value) && !isFalsy(value) {
+		return def
+	}
+
+	return Bool(name)
+}
+
+// Values returns every value passed to a Multiple Argument in the order they were passed.
+// (e.g. --arg=a --arg b -a=c)
+// For an Argument that is not Multiple, or a Multiple Argument that was not passed, only the value returned by Value is included,
+// such as from a loaded config, its EnvVar or its DefaultValue.
+// An empty slice is returned if the Argument was not passed and does not have a value.
+func Values(name string) []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	var arg, found = lookupArg(name)
+	if !found || !arg.Multiple || len(occurrences[arg.key()]) == 0 {
+		if value := valueOf(name); value != "" || using(name) {
+			return []string{value}
+		}
+		return []string{}
+	}
+
+	var values = make([]string, len(occurrences[arg.key()]))
+	copy(values, occurrences[arg.key()])
+
+	return values
+}
+
+// ValueAt returns the value at index i of the values returned by Values, and whether there is a value at i,
+// without copying the values.
+// For an Argument that is not Multiple, or a Multiple Argument that was not passed, only the value at index 0 exists.
+func ValueAt(name string, i int) (string, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	var arg, found = lookupArg(name)
+	if !found || !arg.Multiple || len(occurrences[arg.key()]) == 0 {
+		if value := valueOf(name); i == 0 && (value != "" || using(name)) {
+			return value, true
+		}
+		return "", false
+	}
+
+	var values = occurrences[arg.key()]
+	if i < 0 || i >= len(values) {
+		return "", false
+	}
+
+	return values[i], true
+}
+
+// Slice returns the value of an Argument split on its Separator, or a comma if it does not have one.
+// (e.g. --tags=a,b,c)
+// Whitespace around each element is trimmed and empty elements are skipped.
+// A separator can be escaped with a backslash to include it in an element (e.g. a\,b).
+// An empty slice is returned if the Argument does not have a value.
+func Slice(name string) []string {
+	var separator = ","
+	if arg, found := lookupRegistered(name); found && arg.Separator != "" {
+		separator = arg.Separator
+	}
+
+	var elements = []string{}
+	var element strings.Builder
+	var value = Value(name)
+	for value != "" {
+		if strings.HasPrefix(value, "\\"+separator) {
+			element.WriteString(separator)
+			value = value[len(separator)+1:]
+			continue
+		}
+		if strings.HasPrefix(value, separator) {
+			elements = appendElement(elements, element.String())
+			element.Reset()
+			value = value[len(separator):]
+			continue
+		}
+		var r, size = utf8.DecodeRuneInString(value)
+		element.WriteRune(r)
+		value = value[size:]
+	}
+
+	return appendElement(elements, element.String())
+}
+
+// appendElement appends element to elements with its whitespace trimmed, unless it is empty.
+func appendElement(elements []string, element string) []string {
+	if element = strings.TrimSpace(element); element != "" {
+		elements = append(elements, element)
+	}
+
+	return elements
+}
+
+// IntSlice returns the elements of the value of an Argument split like Slice, each parsed as an int (e.g. --ports=80,443).
+// The error returned identifies the first element that is not an integer.
+// An empty slice is returned if the Argument does not have a value.
+func IntSlice(name string) ([]int, error) {
+	var ints = []int{}
+	for i, element := range Slice(name) {
+		var n, err = strconv.Atoi(element)
+		if err != nil {
+			return nil, fmt.Errorf("%s element %d expects an integer value, got \"%s\": %w", flagName(name), i, element, err)
+		}
+		ints = append(ints, n)
+	}
+
+	return ints, nil
+}
+
+// Float64Slice returns the elements of the value of an Argument split like Slice, each parsed as a float64 (e.g. --ratios=0.5,1.5).
+// The error returned identifies the first element that is not a number.
+// An empty slice is returned if the Argument does not have a value.
+func Float64Slice(name string) ([]float64, error) {
+	var floats = []float64{}
+	for i, element := range Slice(name) {
+		var f, err = strconv.ParseFloat(element, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s element %d expects a number value, got \"%s\": %w", flagName(name), i, element, err)
+		}
+		floats = append(floats, f)
+	}
+
+	return floats, nil
+}
+
+// StringMap returns the values of an Argument as a map of key=value pairs (e.g. --label env=prod --label team=core).
+// If a key is passed more than once, the last value takes precedence.
+// Values that are not a key=value pair are skipped.
+func StringMap(name string) map[string]string {
+	var pairs = make(map[string]string)
+	for _, value := range Values(name) {
+		var key, pairValue, found = strings.Cut(value, "=")
+		if !found || key == "" {
+			continue
+		}
+		pairs[key] = pairValue
+	}
+
+	return pairs
+}
+
+// AllValues returns the value of every registered Argument by its Name, or its Short if it does not have a Name,
+// resolved the same way as Value (e.g. falling back to its EnvVar or DefaultValue).
+func AllValues() map[string]string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return allValues()
+}
+
+// allValues is AllValues for a caller that holds mutex.
+func allValues() map[string]string {
+	var values = make(map[string]string)
+	for _, r := range registered {
+		values[r.key()] = valueOf(r.key())
+	}
+
+	return values
+}
+
+// AllValuesWithUnknown returns the same values as AllValues,
+// including the values of flags that were passed that are not registered arguments (see UnknownFlags).
+func AllValuesWithUnknown() map[string]string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var values = allValues()
+	for _, key := range unknownFlags() {
+		values[key] = Args[key]
+	}
+
+	return values
+}
+
+// jsonValue is the JSON representation of the value of an Argument in DumpJSON.
+type jsonValue struct {
+	Value interface{} `json:"value"`
+	Set   bool        `json:"set"`
+}
+
+// DumpJSON returns a JSON object of every registered Argument by its Name, or its Short if it does not have a Name, sorted by name,
+// with its value resolved the same way as Value and whether it was actually passed (see Lookup).
+// (e.g. {"arg":{"value":"default","set":false}})
+// The value of an Argument that does not expect a value is a boolean, as returned by Bool.
+// The value of an Argument with a Type of TypeInt, TypeFloat or TypeBool is a number or boolean, if it parses as its Type.
+func DumpJSON() ([]byte, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var values = make(map[string]jsonValue)
+	for _, r := range registered {
+		var _, set = lookup(r.key())
+		if r.ExpectsValue {
+			values[r.key()] = jsonValue{Value: typedValue(r, valueOf(r.key())), Set: set}
+		} else {
+			values[r.key()] = jsonValue{Value: boolOf(r.key()), Set: set}
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+// Count returns the number of times an Argument was passed, by its Name, Short or one of its Aliases.
+// (e.g. 3 for -vvv or --verbose -v -v)
+func Count(name string) int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	markRead(name)
+	if arg, found := lookupArg(name); found {
+		name = arg.key()
+	}
+
+	return len(occurrences[name])
+}
+
+// markRead records that the Argument that name refers to was read, for a caller that holds mutex.
+func markRead(name string) {
+	if arg, found := lookupArg(name); found {
+		readMutex.Lock()
+		read[arg.key()] = true
+		readMutex.Unlock()
+	}
+}
+
+// UnusedFlags returns the flags of the registered arguments that were passed but never read (e.g. --arg),
+// in the order they were registered, such as to find flags that are no longer used.
+// An Argument is read by Using, Value, Lookup, Values, ValueAt, Count and the functions built on them (e.g. Int).
+// Validate, DeprecationWarnings, Visit, AllValues, AllValuesWithUnknown and DumpJSON do not read arguments,
+// so that they can be used for logging.
+func UnusedFlags() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	readMutex.Lock()
+	defer readMutex.Unlock()
+
+	var unused = []string{}
+	for _, r := range registered {
+		if len(occurrences[r.key()]) != 0 && !read[r.key()] {
+			unused = append(unused, r.flag())
+		}
+	}
+
+	return unused
+}
+
+// MustValue returns the value of an Argument like Value,
+// but panics if the Argument was not passed and does not have a DefaultValue.
+// Must functions are meant for initializing your program, not for handling errors at runtime.
+func MustValue(name string) string {
+	if !Using(name) {
+		if arg, found := lookupRegistered(name); !found || arg.defaultValue() == "" {
+			panic(fmt.Sprintf("%s was not passed and does not have a default value", flagName(name)))
+		}
+	}
+
+	return Value(name)
+}
+
+// MustInt returns the value of an Argument like Int,
+// but panics if the Argument was not passed and does not have a DefaultValue or its value is not an integer.
+func MustInt(name string) int {
+	MustValue(name)
+
+	var i, err = Int(name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return i
+}
+
+// MustFloat returns the value of an Argument like Float,
+// but panics if the Argument was not passed and does not have a DefaultValue or its value is not a number.
+func MustFloat(name string) float64 {
+	MustValue(name)
+
+	var f, err = Float(name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return f
+}
+
+// MustDuration returns the value of an Argument like Duration,
+// but panics if the Argument was not passed and does not have a DefaultValue or its value is not a duration.
+func MustDuration(name string) time.Duration {
+	MustValue(name)
+
+	var d, err = Duration(name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return d
 }
@@ -5,12 +5,20 @@
 package args
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestArgs(t *testing.T) {
+	t.Cleanup(Reset)
 	Register(Argument{
 		Name:         "arg",
 		Short:        "a",
@@ -41,10 +49,7 @@ func TestArgs(t *testing.T) {
 	})
 	fmt.Print("Registered Argument \"long-example\"\n\n")
 
-	os.Args[1] = "--arg=test"
-	os.Args[2] = "-e"
-
-	parseArgs()
+	Parse([]string{"--arg=test", "-e"})
 
 	for _, arg := range registered {
 		if Using(arg.Name) {
@@ -66,3 +71,2454 @@ func TestArgs(t *testing.T) {
 
 	PrintUsage()
 }
+
+// setArgs clears registered arguments and parses argv for the duration of the test.
+// COLUMNS is cleared so that descriptions in the usage message are not wrapped to the terminal running the test.
+func setArgs(t *testing.T, argv ...string) {
+	t.Setenv("COLUMNS", "")
+	t.Cleanup(Reset)
+	Reset()
+	Parse(argv)
+}
+
+func TestSpaceSeparatedValues(t *testing.T) {
+	setArgs(t, "--output", "file.txt", "-n", "--verbose", "-i", "in.txt", "--flag")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "name", Short: "n", ExpectsValue: true})
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "input", Short: "i", ExpectsValue: true})
+	Register(Argument{Name: "flag", ExpectsValue: true})
+
+	if Value("output") != "file.txt" {
+		t.Errorf("expected --output to have value \"file.txt\", got %q", Value("output"))
+	}
+	if _, ok := Args["file.txt"]; ok {
+		t.Error("expected \"file.txt\" to be consumed as a value")
+	}
+	if Value("name") != "" || !Using("verbose") {
+		t.Error("expected -n to not consume --verbose as its value")
+	}
+	if Value("input") != "in.txt" {
+		t.Errorf("expected -i to have value \"in.txt\", got %q", Value("input"))
+	}
+	if !Using("flag") || Value("flag") != "" {
+		t.Error("expected --flag to be used with no value")
+	}
+}
+
+func TestInt(t *testing.T) {
+	setArgs(t, "--count=5", "-b=abc")
+	Register(Argument{Name: "count", ExpectsValue: true})
+	Register(Argument{Name: "bad", Short: "b", ExpectsValue: true})
+	Register(Argument{Name: "retries", ExpectsValue: true, DefaultValue: "3"})
+	Register(Argument{Name: "unset", ExpectsValue: true})
+
+	var tests = map[string]int{"count": 5, "retries": 3, "unset": 0}
+	for name, expected := range tests {
+		var i, err = Int(name)
+		if err != nil {
+			t.Errorf("--%s: unexpected error: %s", name, err)
+		}
+		if i != expected {
+			t.Errorf("--%s: expected %d, got %d", name, expected, i)
+		}
+	}
+
+	if _, err := Int("bad"); err == nil || !strings.Contains(err.Error(), "--bad") {
+		t.Errorf("expected error naming --bad, got %v", err)
+	}
+}
+
+func TestBool(t *testing.T) {
+	setArgs(t, "--verbose", "-c=Yes", "--debug=off", "--trace=0")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "color", Short: "c", ExpectsValue: true})
+	Register(Argument{Name: "debug", ExpectsValue: true})
+	Register(Argument{Name: "trace", ExpectsValue: true, DefaultValue: "true"})
+	Register(Argument{Name: "cache", ExpectsValue: true, DefaultValue: "on"})
+	Register(Argument{Name: "quiet"})
+
+	var tests = map[string]bool{
+		"verbose": true,
+		"color":   true,
+		"debug":   false,
+		"trace":   false,
+		"cache":   true,
+		"quiet":   false,
+	}
+	for name, expected := range tests {
+		if Bool(name) != expected {
+			t.Errorf("--%s: expected %t, got %t", name, expected, Bool(name))
+		}
+	}
+}
+
+func TestFloat(t *testing.T) {
+	setArgs(t, "--ratio=0.5", "-s=1e3", "--bad=1,5")
+	Register(Argument{Name: "ratio", ExpectsValue: true})
+	Register(Argument{Name: "scale", Short: "s", ExpectsValue: true})
+	Register(Argument{Name: "bad", ExpectsValue: true})
+	Register(Argument{Name: "threshold", ExpectsValue: true, DefaultValue: "2.25"})
+
+	var tests = map[string]float64{"ratio": 0.5, "scale": 1000, "threshold": 2.25}
+	for name, expected := range tests {
+		var f, err = Float(name)
+		if err != nil {
+			t.Errorf("--%s: unexpected error: %s", name, err)
+		}
+		if f != expected {
+			t.Errorf("--%s: expected %g, got %g", name, expected, f)
+		}
+	}
+
+	if _, err := Float("bad"); err == nil || !strings.Contains(err.Error(), "--bad") || !strings.Contains(err.Error(), "1,5") {
+		t.Errorf("expected error naming --bad and its value, got %v", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	setArgs(t, "--arg=value")
+	Register(Argument{Name: "arg", ExpectsValue: true})
+	CustomUsage = "[file]"
+
+	Reset()
+	if len(registered) != 0 || len(Args) != 0 {
+		t.Error("expected registered arguments and args to be cleared")
+	}
+	if CustomUsage != "[file]" {
+		t.Error("expected CustomUsage to not be cleared")
+	}
+	CustomUsage = ""
+
+	Register(Argument{Name: "arg", ExpectsValue: true})
+	Parse([]string{"--arg", "other"})
+	if Value("arg") != "other" {
+		t.Errorf("expected --arg to have value \"other\", got %q", Value("arg"))
+	}
+}
+
+func TestDashesInValues(t *testing.T) {
+	setArgs(t, "--name=a--b", "some-value", "-x=a--b", "--dry-run")
+	Register(Argument{Name: "name", ExpectsValue: true})
+	Register(Argument{Name: "x", ExpectsValue: true})
+	Register(Argument{Name: "dry-run"})
+
+	if Value("name") != "a--b" {
+		t.Errorf("expected --name to have value \"a--b\", got %q", Value("name"))
+	}
+	if Value("x") != "a--b" {
+		t.Errorf("expected -x to have value \"a--b\", got %q", Value("x"))
+	}
+	if !Using("dry-run") {
+		t.Error("expected --dry-run to be used")
+	}
+	if positional := Positional(); len(positional) != 1 || positional[0] != "some-value" {
+		t.Errorf("expected \"some-value\" to not be trimmed, got %v", positional)
+	}
+}
+
+func TestEqualsInValues(t *testing.T) {
+	setArgs(t, "--query=key=value&x=1", "-e=")
+	Register(Argument{Name: "query", ExpectsValue: true})
+	Register(Argument{Name: "empty", Short: "e", ExpectsValue: true})
+
+	if Value("query") != "key=value&x=1" {
+		t.Errorf("expected --query to have value \"key=value&x=1\", got %q", Value("query"))
+	}
+	if !Using("empty") || Value("empty") != "" {
+		t.Error("expected -e to be used with an empty value")
+	}
+}
+
+// setOnMissing replaces OnMissing with a hook that records the missing flags for the duration of the test.
+func setOnMissing(t *testing.T) *[]string {
+	var missing = new([]string)
+	OnMissing = func(m []string) {
+		*missing = m
+	}
+	t.Cleanup(func() {
+		OnMissing = DefaultOnMissing
+	})
+
+	return missing
+}
+
+func TestValidateRequired(t *testing.T) {
+	setOnMissing(t)
+	setArgs(t, "--input=file.txt")
+	Register(Argument{Name: "input", ExpectsValue: true, Required: true})
+	Register(Argument{Name: "format", ExpectsValue: true, Required: true, DefaultValue: "json"})
+	if err := Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true})
+	Register(Argument{Name: "mode", Short: "m", Required: true})
+	var err = Validate()
+	if err == nil || err.Error() != "missing required argument: --mode\nmissing required argument: --output" {
+		t.Errorf("expected missing --output and --mode sorted by flag, got %v", err)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "format", ExpectsValue: true, DefaultValue: "yaml", Values: []string{"json", "xml"}})
+	Register(Argument{Name: "port", ExpectsValue: true, DefaultValue: "abc", Type: TypeInt})
+	Register(Argument{Name: "level", ExpectsValue: true, DefaultValue: "info", Values: []string{"info", "debug"}})
+
+	var expected = "--format has invalid value \"yaml\", expected one of: json, xml\n" +
+		"--port expects an integer value: strconv.Atoi: parsing \"abc\": invalid syntax"
+	if err := Validate(); err == nil || err.Error() != expected {
+		t.Errorf("expected invalid defaults to be errors, got %v", err)
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	setArgs(t, "--format=yaml", "--level", "debug")
+	Register(Argument{Name: "format", ExpectsValue: true, Values: []string{"json", "xml"}})
+	Register(Argument{Name: "level", ExpectsValue: true, Values: []string{"debug", "info"}})
+
+	var err = Validate()
+	if err == nil || err.Error() != "--format has invalid value \"yaml\", expected one of: json, xml" {
+		t.Errorf("expected invalid value error for --format, got %v", err)
+	}
+
+	Parse([]string{"--format=json", "--level", "debug"})
+	if err = Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	Parse([]string{"--format=JSON"})
+	if err = Validate(); err == nil {
+		t.Error("expected values to be compared exactly")
+	}
+
+	setArgs(t, "--include=bad", "--include=a", "--include=worse")
+	Register(Argument{Name: "include", ExpectsValue: true, Multiple: true, Values: []string{"a", "b"}})
+	err = Validate()
+	var expected = "--include has invalid value \"bad\", expected one of: a, b\n" +
+		"--include has invalid value \"worse\", expected one of: a, b"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected every value of a multiple argument to be checked, got %v", err)
+	}
+}
+
+func TestMultipleValues(t *testing.T) {
+	setArgs(t, "--include=a", "--include", "b", "-i=c", "--exclude=x", "--exclude=y")
+	Register(Argument{Name: "include", Short: "i", ExpectsValue: true, Multiple: true})
+	Register(Argument{Name: "exclude", ExpectsValue: true})
+	Register(Argument{Name: "unset", ExpectsValue: true, Multiple: true})
+
+	if values := Values("include"); strings.Join(values, ",") != "a,b,c" {
+		t.Errorf("expected --include to have values [a b c], got %v", values)
+	}
+	if values := Values("exclude"); len(values) != 1 || values[0] != "y" || Value("exclude") != "y" {
+		t.Errorf("expected --exclude to have only the last value, got %v", values)
+	}
+	if values := Values("unset"); values == nil || len(values) != 0 {
+		t.Errorf("expected --unset to have an empty slice, got %#v", values)
+	}
+}
+
+func TestRegisterErr(t *testing.T) {
+	setArgs(t)
+	if err := RegisterErr(Argument{Name: "arg", Short: "a", ExpectsValue: true}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = map[string]Argument{
+		"--default has a default value but does not expect value":      {Name: "default", DefaultValue: "value"},
+		"--multiple accepts multiple values but does not expect value": {Name: "multiple", Multiple: true},
+		"--arg is already a registred argument":                        {Name: "arg"},
+		"-a is already a registred shorthand argument":                 {Name: "other", Short: "a"},
+	}
+	for expected, arg := range tests {
+		var err = RegisterErr(arg)
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+	if len(registered) != 1 {
+		t.Errorf("expected only one registered argument, got %d", len(registered))
+	}
+
+	if err := RegisterErr(Argument{Name: "a"}); err == nil || err.Error() != "-a is already a registred shorthand argument" {
+		t.Errorf("expected a name matching a registered shorthand argument to be an error, got %v", err)
+	}
+	Register(Argument{Name: "x"})
+	if err := RegisterErr(Argument{Name: "y", Short: "x"}); err == nil || err.Error() != "-x is already a registred argument" {
+		t.Errorf("expected a shorthand argument matching a registered name to be an error, got %v", err)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	setArgs(t, "-h")
+	Register(Argument{Name: "host", Short: "h", ExpectsValue: true})
+	EnableHelp()
+	EnableHelp()
+
+	if arg, found := lookupArg("help"); !found || arg.Short != "" {
+		t.Errorf("expected --help to be registered without a shorthand, got %+v", arg)
+	}
+	if HandleHelp() {
+		t.Error("expected -h to not be handled as --help")
+	}
+
+	setArgs(t, "-h")
+	EnableHelp()
+	if !HandleHelp() {
+		t.Error("expected -h to be handled as --help")
+	}
+
+	setArgs(t, "-h")
+	Register(Argument{Name: "hosts", Aliases: []string{"h"}, ExpectsValue: true})
+	EnableHelp()
+	if arg, found := lookupArg("help"); !found || arg.Short != "" {
+		t.Errorf("expected --help to be registered without a shorthand when -h is an alias, got %+v", arg)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	setArgs(t, "-v")
+	Register(Argument{Name: "verbose", Short: "v"})
+	EnableVersion("1.0.0")
+
+	if arg, found := lookupArg("version"); !found || arg.Short != "" {
+		t.Errorf("expected --version to be registered without a shorthand, got %+v", arg)
+	}
+	if HandleVersion() {
+		t.Error("expected -v to not be handled as --version")
+	}
+
+	setArgs(t, "--version")
+	EnableVersion("1.0.0")
+	if !HandleVersion() {
+		t.Error("expected --version to be handled")
+	}
+
+	setArgs(t, "-v")
+	Register(Argument{Name: "verbose", Aliases: []string{"v"}})
+	EnableVersion("1.0.0")
+	if arg, found := lookupArg("version"); !found || arg.Short != "" {
+		t.Errorf("expected --version to be registered without a shorthand when -v is an alias, got %+v", arg)
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	setArgs(t, "--port=8080")
+	Register(Argument{Name: "port", ExpectsValue: true, EnvVar: "ARGS_TEST_PORT"})
+	Register(Argument{Name: "host", ExpectsValue: true, EnvVar: "ARGS_TEST_HOST", DefaultValue: "localhost"})
+	Register(Argument{Name: "user", ExpectsValue: true, EnvVar: "ARGS_TEST_USER"})
+
+	t.Setenv("ARGS_TEST_PORT", "9090")
+	t.Setenv("ARGS_TEST_HOST", "example.com")
+	t.Setenv("ARGS_TEST_USER", "")
+
+	if Value("port") != "8080" {
+		t.Errorf("expected --port to take precedence over its environment variable, got %q", Value("port"))
+	}
+	if !Using("host") || Value("host") != "example.com" {
+		t.Errorf("expected --host to fall back to its environment variable, got %q", Value("host"))
+	}
+	if Using("user") {
+		t.Error("expected an empty environment variable to be ignored")
+	}
+
+	if err := os.Unsetenv("ARGS_TEST_HOST"); err != nil {
+		t.Fatal(err)
+	}
+	if Using("host") || Value("host") != "localhost" {
+		t.Errorf("expected --host to fall back to its default value, got %q", Value("host"))
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	setArgs(t, "--empty=", "--set=value")
+	Register(Argument{Name: "unset", Short: "u", ExpectsValue: true, DefaultValue: "default"})
+	Register(Argument{Name: "empty", ExpectsValue: true, DefaultValue: "default"})
+	Register(Argument{Name: "set", ExpectsValue: true, DefaultValue: "default"})
+
+	var tests = map[string]string{
+		"unset": "default",
+		"u":     "default",
+		"empty": "",
+		"set":   "value",
+	}
+	for name, expected := range tests {
+		if Value(name) != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, Value(name))
+		}
+	}
+}
+
+func TestDefaultFunc(t *testing.T) {
+	setArgs(t, "--output=out")
+	var calls int
+	var threads = func() string {
+		calls++
+		return strconv.Itoa(4 * calls)
+	}
+	Register(Argument{Name: "threads", ExpectsValue: true, Required: true, Type: TypeInt, DefaultFunc: threads})
+	Register(Argument{Name: "output", ExpectsValue: true, DefaultFunc: func() string { return "computed" }})
+
+	if calls != 0 {
+		t.Error("expected DefaultFunc to not be called when registered")
+	}
+	if i, err := Int("threads"); err != nil || i != 4 {
+		t.Errorf("expected --threads to default to 4, got %d: %v", i, err)
+	}
+	if value := Value("threads"); value != "8" {
+		t.Errorf("expected DefaultFunc to be called each time, got %q", value)
+	}
+	if value := Value("output"); value != "out" {
+		t.Errorf("expected the passed value to take precedence over DefaultFunc, got %q", value)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("expected a required argument with a DefaultFunc to not be missing, got %s", err)
+	}
+
+	var err = RegisterErr(Argument{Name: "both", ExpectsValue: true, DefaultValue: "x", DefaultFunc: threads})
+	if err == nil || err.Error() != "--both has both a default value and a default function" {
+		t.Errorf("expected an error for an argument with a DefaultValue and a DefaultFunc, got %v", err)
+	}
+	if err := RegisterErr(Argument{Name: "flag", DefaultFunc: threads}); err == nil {
+		t.Error("expected an error for a DefaultFunc on an argument that does not expect value")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	setArgs(t, "--empty=", "-s=value")
+	Register(Argument{Name: "unset", ExpectsValue: true, DefaultValue: "default"})
+	Register(Argument{Name: "empty", ExpectsValue: true})
+	Register(Argument{Name: "set", Short: "s", ExpectsValue: true})
+
+	if value, set := Lookup("unset"); set || value != "" {
+		t.Errorf("expected --unset to not be set, got %q, %t", value, set)
+	}
+	if value, set := Lookup("empty"); !set || value != "" {
+		t.Errorf("expected --empty to be set to an empty value, got %q, %t", value, set)
+	}
+	if value, set := Lookup("set"); !set || value != "value" {
+		t.Errorf("expected --set to be set to \"value\", got %q, %t", value, set)
+	}
+}
+
+func TestStackedShorts(t *testing.T) {
+	setArgs(t, "-abc", "-vofile.txt", "-xd", "in.txt", "-az")
+	Register(Argument{Name: "all", Short: "a"})
+	Register(Argument{Name: "bytes", Short: "b"})
+	Register(Argument{Name: "count", Short: "c"})
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "extract", Short: "x"})
+	Register(Argument{Name: "dir", Short: "d", ExpectsValue: true})
+
+	for _, name := range []string{"all", "bytes", "count", "verbose", "extract"} {
+		if !Using(name) {
+			t.Errorf("expected --%s to be used", name)
+		}
+	}
+	if Value("output") != "file.txt" {
+		t.Errorf("expected --output to have value \"file.txt\", got %q", Value("output"))
+	}
+	if Value("dir") != "in.txt" {
+		t.Errorf("expected --dir to have value \"in.txt\", got %q", Value("dir"))
+	}
+	if _, ok := Args["az"]; !ok {
+		t.Error("expected -az to not be split, as -z is not registered")
+	}
+
+	setArgs(t, "-abo=out.txt", "-xd=", "in.txt", "-abc=1")
+	Register(Argument{Name: "all", Short: "a"})
+	Register(Argument{Name: "bytes", Short: "b"})
+	Register(Argument{Name: "count", Short: "c"})
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "extract", Short: "x"})
+	Register(Argument{Name: "dir", Short: "d", ExpectsValue: true})
+
+	if !Using("all") || !Using("bytes") || !Using("count") || !Using("extract") || Value("output") != "out.txt" {
+		t.Errorf("expected -abo=out.txt to be split with out.txt as the value of -o, got %v", Args)
+	}
+	if value, set := Lookup("dir"); !set || value != "" || strings.Join(Positional(), " ") != "in.txt" {
+		t.Errorf("expected -d to have an empty value, got %q", value)
+	}
+	if _, ok := Args["abc"]; ok {
+		t.Error("expected -abc=1 to be split")
+	}
+	if errs := ParseErrors(); len(errs) != 1 || errs[0].Error() != "-c does not expect a value but was given \"1\"" {
+		t.Errorf("expected an error for a value given to -c, got %v", errs)
+	}
+}
+
+func TestTerminator(t *testing.T) {
+	setArgs(t, "--verbose", "--=something", "--", "-file", "--output=x", "--")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+
+	if !Using("verbose") || Using("output") {
+		t.Error("expected only --verbose to be used")
+	}
+	if positional := Positional(); strings.Join(positional, " ") != "-file --output=x --" {
+		t.Errorf("expected args after -- to be positional, got %v", positional)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	setArgs(t, "--v", "--", "child", "--child-flag", "--", "nested")
+	Register(Argument{Name: "v"})
+	Register(Argument{Name: "child-flag"})
+
+	if !Using("v") || Using("child-flag") {
+		t.Error("expected only --v to be used")
+	}
+	if unknown := UnknownFlags(); len(unknown) != 0 {
+		t.Errorf("expected args after -- to not be parsed as flags, got %v", unknown)
+	}
+	var expected = "child --child-flag -- nested"
+	if positional := Positional(); strings.Join(positional, " ") != expected {
+		t.Errorf("expected every arg after the first -- to be positional, got %v", positional)
+	}
+	if after := After("--"); strings.Join(after, " ") != expected {
+		t.Errorf("expected every arg after the first -- to be passed through, got %v", after)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	setArgs(t, "run", "--verbose", "--", "npm", "start", "--", "--port=3000")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "port", ExpectsValue: true})
+
+	if !Using("verbose") || Using("port") {
+		t.Error("expected only flags before -- to be used")
+	}
+	if after := After("--"); strings.Join(after, " ") != "npm start -- --port=3000" {
+		t.Errorf("expected args after the first --, got %v", after)
+	}
+	if after := After("run"); strings.Join(after, " ") != "--verbose -- npm start -- --port=3000" {
+		t.Errorf("expected args after run, got %v", after)
+	}
+	if after := After("exec"); len(after) != 0 {
+		t.Errorf("expected no args after a token that was not passed, got %v", after)
+	}
+}
+
+func TestTerminators(t *testing.T) {
+	Terminators = []string{"exec"}
+	t.Cleanup(func() { Terminators = nil })
+
+	setArgs(t, "--verbose", "run", "exec", "npm", "--version", "--", "-x")
+	Register(Argument{Name: "verbose"})
+	EnableVersion("1.0.0")
+
+	if !Using("verbose") || Using("version") {
+		t.Error("expected only flags before exec to be used")
+	}
+	if unknown := UnknownFlags(); len(unknown) != 0 {
+		t.Errorf("expected flags after exec to not be unknown, got %v", unknown)
+	}
+	if after := After("exec"); strings.Join(after, " ") != "npm --version -- -x" {
+		t.Errorf("expected every arg after exec, got %v", after)
+	}
+	if positional := Positional(); strings.Join(positional, " ") != "run exec npm --version -- -x" {
+		t.Errorf("expected exec and every arg after it to be positional, got %v", positional)
+	}
+
+	setArgs(t, "--output", "exec", "ls")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	if Value("output") != "" || len(ParseErrors()) != 1 {
+		t.Errorf("expected exec to not be the value of --output, got %q", Value("output"))
+	}
+}
+
+func TestPositional(t *testing.T) {
+	setArgs(t, "build", "./src", "--verbose", "-o", "out", "main.go", "-", "-t", "--", "-x")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "test", Short: "t"})
+
+	if positional := Positional(); strings.Join(positional, " ") != "build ./src main.go - -x" {
+		t.Errorf("expected positional args in order, got %v", positional)
+	}
+	if len(Args) != 3 {
+		t.Errorf("expected only flags in Args, got %v", Args)
+	}
+}
+
+func TestUnknownFlags(t *testing.T) {
+	setArgs(t, "--verbsoe", "-v", "--output=x", "-z", "file")
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+
+	if unknown := UnknownFlags(); strings.Join(unknown, " ") != "verbsoe z" {
+		t.Errorf("expected verbsoe and z to be unknown, got %v", unknown)
+	}
+}
+
+func TestSlashFlags(t *testing.T) {
+	setArgs(t, "/o:file.txt", "/verbose", "/etc/passwd")
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "verbose"})
+	if Using("output") || Using("verbose") {
+		t.Error("expected slash flags to be positional when not allowed")
+	}
+	if positional := Positional(); len(positional) != 3 {
+		t.Errorf("expected 3 positional args, got %v", positional)
+	}
+
+	AllowSlashFlags = true
+	t.Cleanup(func() {
+		AllowSlashFlags = false
+	})
+	Parse([]string{"/o:file.txt", "/verbose", "-x=/etc/passwd"})
+	if Value("output") != "file.txt" {
+		t.Errorf("expected /o to have value \"file.txt\", got %q", Value("output"))
+	}
+	if !Using("verbose") {
+		t.Error("expected /verbose to be used")
+	}
+	if Value("x") != "/etc/passwd" {
+		t.Errorf("expected -x to have value \"/etc/passwd\", got %q", Value("x"))
+	}
+}
+
+func TestDuration(t *testing.T) {
+	setArgs(t, "--timeout=30s", "-i", "1h30m", "--bad=30")
+	Register(Argument{Name: "timeout", ExpectsValue: true})
+	Register(Argument{Name: "interval", Short: "i", ExpectsValue: true})
+	Register(Argument{Name: "bad", ExpectsValue: true})
+	Register(Argument{Name: "wait", ExpectsValue: true, DefaultValue: "5m"})
+
+	var tests = map[string]time.Duration{
+		"timeout":  30 * time.Second,
+		"interval": 90 * time.Minute,
+		"wait":     5 * time.Minute,
+	}
+	for name, expected := range tests {
+		var d, err = Duration(name)
+		if err != nil {
+			t.Errorf("--%s: unexpected error: %s", name, err)
+		}
+		if d != expected {
+			t.Errorf("--%s: expected %s, got %s", name, expected, d)
+		}
+	}
+
+	if _, err := Duration("bad"); err == nil || !strings.Contains(err.Error(), "--bad") {
+		t.Errorf("expected error naming --bad, got %v", err)
+	}
+}
+
+func TestSortUsage(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "all", Short: "a"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+
+	if flags := availableFlags(); flags != "-v -a --output=" {
+		t.Errorf("expected flags in registration order, got %q", flags)
+	}
+
+	SortUsage = true
+	t.Cleanup(func() {
+		SortUsage = false
+	})
+	if flags := availableFlags(); flags != "-a --output= -v" {
+		t.Errorf("expected flags sorted by name, got %q", flags)
+	}
+}
+
+func TestAvailableFlags(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	if flags := availableFlags(); flags != "-o=" {
+		t.Errorf("expected a single flag, got %q", flags)
+	}
+
+	Register(Argument{Name: "verbose"})
+	if flags := availableFlags(); flags != "-o= --verbose" {
+		t.Errorf("expected two flags separated by a space, got %q", flags)
+	}
+	if line := strings.SplitN(usage(), "\n", 2)[0]; !strings.HasSuffix(line, " [-o= --verbose]") {
+		t.Errorf("expected usage line to end with the flags, got %q", line)
+	}
+}
+
+func TestUsageAlignment(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "arg", Short: "a", Description: "Value with short", ExpectsValue: true, DefaultValue: "x"})
+	Register(Argument{Name: "test", Short: "t", Description: "Boolean with short"})
+	Register(Argument{Name: "no-short", Description: "Value without short", ExpectsValue: true})
+	Register(Argument{Name: "examples-can-be-longer", Description: "Boolean without short"})
+	Register(Argument{Name: "quiet", Short: "q"})
+
+	var expected = "Options:\n" +
+		"\t-a=  --arg=                    Value with short [default=x]\n" +
+		"\t-t   --test                    Boolean with short\n" +
+		"\t     --no-short=               Value without short\n" +
+		"\t     --examples-can-be-longer  Boolean without short\n" +
+		"\t-q   --quiet\n"
+	var buf bytes.Buffer
+	if err := FprintUsage(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var lines = strings.SplitN(buf.String(), "\n", 2)
+	if lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestFprintUsageError(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "arg"})
+	if err := FprintUsage(errWriter{}); err == nil {
+		t.Error("expected write error to be returned")
+	}
+}
+
+func TestUsageGroups(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "verbose", Short: "v", Group: "Output"})
+	Register(Argument{Name: "help", Short: "h"})
+	Register(Argument{Name: "host", ExpectsValue: true, Group: "Network"})
+	Register(Argument{Name: "quiet", Short: "q", Group: "Output"})
+
+	var expected = "Options:\n" +
+		"\t-h  --help\n" +
+		"\n" +
+		"Network:\n" +
+		"\t    --host=\n" +
+		"\n" +
+		"Output:\n" +
+		"\t-v  --verbose\n" +
+		"\t-q  --quiet\n"
+	var lines = strings.SplitN(usage(), "\n", 2)
+	if lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	WarnDeprecated = false
+	t.Cleanup(func() {
+		WarnDeprecated = true
+	})
+
+	setArgs(t, "--old=value")
+	Register(Argument{Name: "old", ExpectsValue: true, Deprecated: "use --new instead"})
+	Register(Argument{Name: "older", Deprecated: "use --new instead"})
+	Register(Argument{Name: "new", ExpectsValue: true})
+
+	if Value("old") != "value" {
+		t.Errorf("expected deprecated --old to still have a value, got %q", Value("old"))
+	}
+	if warnings := DeprecationWarnings(); len(warnings) != 1 || warnings[0] != "--old is deprecated: use --new instead" {
+		t.Errorf("expected a warning for only --old, got %v", warnings)
+	}
+
+	Parse([]string{"--new=value"})
+	if warnings := DeprecationWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestDeprecatedWarnOnce(t *testing.T) {
+	if os.Getenv("ARGS_TEST_WARN_ONCE") != "" {
+		setArgs(t, "--old", "build", "--old")
+		Register(Argument{Name: "old", Deprecated: "use --new instead"})
+		Parse([]string{"--old", "build", "--old"})
+		Command("build")
+		if _, err := Dispatch(); err != nil {
+			t.Fatal(err)
+		}
+		Parse([]string{"--old"})
+		return
+	}
+
+	var cmd = exec.Command(os.Args[0], "-test.run=^TestDeprecatedWarnOnce$")
+	cmd.Env = append(os.Environ(), "ARGS_TEST_WARN_ONCE=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%s: %s", err, stderr.String())
+	}
+	if count := strings.Count(stderr.String(), "--old is deprecated: use --new instead\n"); count != 2 {
+		t.Errorf("expected a warning once for each list of args parsed, got %d:\n%s", count, stderr.String())
+	}
+}
+
+func TestAliases(t *testing.T) {
+	setArgs(t, "--colour=always", "-k", "--size", "3")
+	Register(Argument{Name: "color", ExpectsValue: true, Aliases: []string{"colour"}})
+	Register(Argument{Name: "keep", Aliases: []string{"k", "retain"}})
+	Register(Argument{Name: "count", ExpectsValue: true, Aliases: []string{"size"}})
+
+	if !Using("color") || Value("color") != "always" {
+		t.Errorf("expected --color to have value \"always\", got %q", Value("color"))
+	}
+	if !Bool("keep") {
+		t.Error("expected --keep to be used by its alias -k")
+	}
+	if i, err := Int("count"); err != nil || i != 3 {
+		t.Errorf("expected --count to be 3, got %d, %v", i, err)
+	}
+	if unknown := UnknownFlags(); len(unknown) != 0 {
+		t.Errorf("expected aliases to not be unknown, got %v", unknown)
+	}
+
+	if err := RegisterErr(Argument{Name: "tint", Aliases: []string{"colour"}}); err == nil || err.Error() != "--tint alias colour is already registered by --color" {
+		t.Errorf("expected alias collision error, got %v", err)
+	}
+	if err := RegisterErr(Argument{Name: "retain"}); err == nil || err.Error() != "retain is already a registered alias of --keep" {
+		t.Errorf("expected alias collision error, got %v", err)
+	}
+
+	Register(Argument{Name: "euro", Aliases: []string{"€"}})
+	if details := usageDetails(registered[len(registered)-1]); details != "["+Strings.Aliases+"=-€]" {
+		t.Errorf("expected a single character alias to be printed as a shorthand argument, got %q", details)
+	}
+}
+
+func TestNegatable(t *testing.T) {
+	setArgs(t, "--no-color")
+	Register(Argument{Name: "color", Negatable: true, DefaultValue: "true"})
+	Register(Argument{Name: "cache", Negatable: true})
+	Register(Argument{Name: "strict"})
+
+	if Bool("color") {
+		t.Error("expected --no-color to override the default value")
+	}
+	if Bool("cache") {
+		t.Error("expected --cache to be false")
+	}
+	if unknown := UnknownFlags(); len(unknown) != 0 {
+		t.Errorf("expected --no-color to not be unknown, got %v", unknown)
+	}
+
+	Parse([]string{"--no-color", "--color", "--cache", "--no-cache", "--no-strict"})
+	if !Bool("color") {
+		t.Error("expected --color after --no-color to be true")
+	}
+	if Bool("cache") {
+		t.Error("expected --no-cache after --cache to be false")
+	}
+	if Bool("strict") {
+		t.Error("expected --strict to not be negatable")
+	}
+
+	Parse(nil)
+	if !Bool("color") {
+		t.Error("expected --color to default to true")
+	}
+
+	if err := RegisterErr(Argument{Name: "level", Negatable: true, ExpectsValue: true}); err == nil {
+		t.Error("expected a negatable argument that expects a value to be rejected")
+	}
+}
+
+// expectPanic fails the test if fn does not panic with a message containing expected.
+func expectPanic(t *testing.T, expected string, fn func()) {
+	t.Helper()
+	defer func() {
+		var r = recover()
+		if r == nil {
+			t.Errorf("expected panic containing %q", expected)
+			return
+		}
+		if msg := fmt.Sprint(r); !strings.Contains(msg, expected) {
+			t.Errorf("expected panic containing %q, got %q", expected, msg)
+		}
+	}()
+	fn()
+}
+
+func TestMust(t *testing.T) {
+	setArgs(t, "--name=value", "--count=abc")
+	Register(Argument{Name: "name", ExpectsValue: true})
+	Register(Argument{Name: "count", ExpectsValue: true})
+	Register(Argument{Name: "retries", ExpectsValue: true, DefaultValue: "3"})
+	Register(Argument{Name: "timeout", ExpectsValue: true})
+
+	if MustValue("name") != "value" {
+		t.Errorf("expected --name to have value \"value\", got %q", MustValue("name"))
+	}
+	if MustInt("retries") != 3 {
+		t.Errorf("expected --retries to default to 3, got %d", MustInt("retries"))
+	}
+
+	expectPanic(t, "--timeout", func() {
+		MustDuration("timeout")
+	})
+	expectPanic(t, "--count", func() {
+		MustInt("count")
+	})
+}
+
+func TestCount(t *testing.T) {
+	var tests = []struct {
+		argv  []string
+		count int
+	}{
+		{[]string{"-v", "-v"}, 2},
+		{[]string{"-vv"}, 2},
+		{[]string{"--verbose", "-vv"}, 3},
+		{[]string{"-qvv", "--verbose", "-v"}, 4},
+	}
+	for _, test := range tests {
+		setArgs(t, test.argv...)
+		Register(Argument{Name: "verbose", Short: "v"})
+		Register(Argument{Name: "quiet", Short: "q"})
+		if count := Count("verbose"); count != test.count {
+			t.Errorf("%v: expected %d, got %d", test.argv, test.count, count)
+		}
+	}
+
+	setArgs(t)
+	Register(Argument{Name: "verbose", Short: "v"})
+	if count := Count("verbose"); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+}
+
+func TestAllValues(t *testing.T) {
+	setArgs(t, "--output=file.txt", "--verbose", "--unknown=value")
+	Register(Argument{Name: "output", ExpectsValue: true, DefaultValue: "out.txt"})
+	Register(Argument{Name: "format", ExpectsValue: true, DefaultValue: "json"})
+	Register(Argument{Name: "verbose"})
+
+	var expected = map[string]string{"output": "file.txt", "format": "json", "verbose": ""}
+	var values = AllValues()
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	expected["unknown"] = "value"
+	values = AllValuesWithUnknown()
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
+
+func TestExplicitEmptyValue(t *testing.T) {
+	setArgs(t, "--output=", "--count=")
+	Register(Argument{Name: "output", ExpectsValue: true, DefaultValue: "out.txt"})
+	Register(Argument{Name: "count", ExpectsValue: true, DefaultValue: "3"})
+
+	if value, set := Lookup("output"); !set || value != "" {
+		t.Errorf("expected --output to be set to an empty value, got %q, %t", value, set)
+	}
+	if Value("output") != "" {
+		t.Errorf("expected an empty value to override the default value, got %q", Value("output"))
+	}
+	if i, err := Int("count"); err != nil || i != 0 {
+		t.Errorf("expected an empty value to override the default value, got %d, %v", i, err)
+	}
+}
+
+func TestSingleCharacterShort(t *testing.T) {
+	setArgs(t, "-üv")
+	if err := RegisterErr(Argument{Name: "verbose", Short: "verbose"}); err == nil || err.Error() != "-verbose is not a single character shorthand argument" {
+		t.Errorf("expected multi-character shorthand to be rejected, got %v", err)
+	}
+	if err := RegisterErr(Argument{Name: "umlaut", Short: "ü"}); err != nil {
+		t.Errorf("expected single rune shorthand to be accepted, got %v", err)
+	}
+	if err := RegisterErr(Argument{Name: "verbose", Short: "v"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if !Using("umlaut") || !Using("verbose") {
+		t.Error("expected -üv to be split into -ü and -v")
+	}
+}
+
+func TestOnMissing(t *testing.T) {
+	var missing = setOnMissing(t)
+	setArgs(t, "--input=file.txt")
+	Register(Argument{Name: "input", ExpectsValue: true, Required: true})
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true})
+	Register(Argument{Name: "mode", Required: true})
+
+	if err := Validate(); err == nil {
+		t.Error("expected an error after OnMissing returns")
+	}
+	if strings.Join(*missing, " ") != "--output --mode" {
+		t.Errorf("expected OnMissing to be called with --output and --mode, got %v", *missing)
+	}
+}
+
+func TestUsageWithoutProgramName(t *testing.T) {
+	var osArgs = os.Args
+	t.Cleanup(func() {
+		os.Args = osArgs
+	})
+	os.Args = []string{}
+
+	setArgs(t)
+	Register(Argument{Name: "arg"})
+	if usage := usage(); !strings.HasPrefix(usage, "USAGE: program ") {
+		t.Errorf("expected usage to fall back to a generic program name, got:\n%s", usage)
+	}
+}
+
+func TestSetArgs(t *testing.T) {
+	var argv = []string{"build", "--output=a.out", "-v"}
+	setArgs(t, argv...)
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	var positional = Positional()
+	if len(positional) == 0 || positional[0] != "build" {
+		t.Fatalf("expected the subcommand to be positional, got %v", positional)
+	}
+
+	Register(Argument{Name: "output", ExpectsValue: true})
+	SetArgs(argv[1:])
+	if Value("output") != "a.out" || !Using("verbose") {
+		t.Errorf("expected the args after the subcommand to be parsed, got %v", Args)
+	}
+
+	SetArgs([]string{"--output=b.out"})
+	if Value("output") != "b.out" || Using("verbose") {
+		t.Errorf("expected Args to reflect the latest args, got %v", Args)
+	}
+	if len(registered) != 2 {
+		t.Errorf("expected registered arguments to be intact, got %d", len(registered))
+	}
+}
+
+func TestUsingNameAndShort(t *testing.T) {
+	var tests = []struct {
+		passed string
+		query  string
+	}{
+		{"--arg", "arg"},
+		{"--arg", "a"},
+		{"-a", "arg"},
+		{"-a", "a"},
+	}
+	for _, test := range tests {
+		setArgs(t, test.passed+"=value")
+		Register(Argument{Name: "arg", Short: "a", ExpectsValue: true})
+		Register(Argument{Name: "other", Short: "o", ExpectsValue: true})
+		if !Using(test.query) || Value(test.query) != "value" {
+			t.Errorf("passed %s, expected %s to have value \"value\", got %q", test.passed, test.query, Value(test.query))
+		}
+		if Using("other") || Using("o") {
+			t.Errorf("passed %s, expected --other to not be used", test.passed)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	setArgs(t, "--=foo", "-=x", "-", "--verbose", "--", "--=bar")
+	Register(Argument{Name: "verbose"})
+
+	var errs = ParseErrors()
+	if len(errs) != 2 || errs[0].Error() != "--=foo is missing a flag name" || errs[1].Error() != "-=x is missing a flag name" {
+		t.Errorf("expected errors for --=foo and -=x, got %v", errs)
+	}
+	if _, ok := Args[""]; ok {
+		t.Error("expected malformed flags to not be stored in Args")
+	}
+	if positional := Positional(); strings.Join(positional, " ") != "- --=bar" {
+		t.Errorf("expected - and args after -- to be positional, got %v", positional)
+	}
+
+	Parse([]string{"--verbose"})
+	if errs = ParseErrors(); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestIgnoreMalformed(t *testing.T) {
+	IgnoreMalformed = true
+	t.Cleanup(func() {
+		IgnoreMalformed = false
+	})
+
+	setArgs(t, "--=value", "-=x", "--verbose")
+	Register(Argument{Name: "verbose"})
+	if errs := ParseErrors(); len(errs) != 0 {
+		t.Errorf("expected malformed flags to be skipped, got %v", errs)
+	}
+	if _, ok := Args[""]; ok || len(Args) != 1 {
+		t.Errorf("expected malformed flags to not be stored in Args, got %v", Args)
+	}
+}
+
+func TestShortAttachedValue(t *testing.T) {
+	for _, argv := range [][]string{{"-n5"}, {"-n=5"}, {"-n", "5"}, {"-vn5"}} {
+		setArgs(t, argv...)
+		Register(Argument{Name: "lines", Short: "n", ExpectsValue: true})
+		Register(Argument{Name: "verbose", Short: "v"})
+		if Value("lines") != "5" {
+			t.Errorf("expected %v to have value \"5\", got %q", argv, Value("lines"))
+		}
+	}
+}
+
+func TestNegativeNumberValue(t *testing.T) {
+	setArgs(t, "--offset", "-5", "-r", "-0.5", "--values", "-1", "-2", "--count", "-3")
+	Register(Argument{Name: "offset", ExpectsValue: true})
+	Register(Argument{Name: "ratio", Short: "r", ExpectsValue: true})
+	Register(Argument{Name: "values", ExpectsValue: true, Multiple: true, Greedy: true})
+	Register(Argument{Name: "count", ExpectsValue: true})
+	Register(Argument{Name: "three", Short: "3"})
+
+	if Value("offset") != "-5" || Value("ratio") != "-0.5" {
+		t.Errorf("expected negative numbers to be values, got %v", Args)
+	}
+	if values := Values("values"); strings.Join(values, " ") != "-1 -2" {
+		t.Errorf("expected negative numbers to be greedy values, got %v", values)
+	}
+	if Value("count") != "" || !Using("three") {
+		t.Error("expected a registered shorthand argument to not be a value")
+	}
+
+	setArgs(t, "-o", "-inf", "--ratio", "-nan", "--offset", "-.5")
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "ratio", ExpectsValue: true})
+	Register(Argument{Name: "offset", ExpectsValue: true})
+	Register(Argument{Short: "i"})
+	Register(Argument{Short: "n"})
+	Register(Argument{Short: "f"})
+	Register(Argument{Short: "a"})
+
+	if Value("output") != "" || Value("ratio") != "" || !Using("i") || !Using("n") || !Using("f") || !Using("a") {
+		t.Errorf("expected -inf and -nan to be stacked shorthand arguments instead of values, got %v", Args)
+	}
+	if Value("offset") != "-.5" {
+		t.Errorf("expected -.5 to be a value, got %q", Value("offset"))
+	}
+}
+
+func TestStdinValue(t *testing.T) {
+	setArgs(t, "-o", "-", "in.txt")
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+
+	if Value("output") != "-" || strings.Join(Positional(), " ") != "in.txt" {
+		t.Errorf("expected - to be the value of -o, got %q", Value("output"))
+	}
+	if errs := ParseErrors(); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("expected arguments to be valid, got %v", err)
+	}
+}
+
+func TestShortSpaceSeparatedValue(t *testing.T) {
+	setArgs(t, "-o", "output.txt")
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "dry-run", Short: "n"})
+	if Value("output") != "output.txt" {
+		t.Errorf("expected -o to have value \"output.txt\", got %q", Value("output"))
+	}
+	if errs := ParseErrors(); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	Parse([]string{"-o", "-n"})
+	if Value("output") != "" || !Using("dry-run") {
+		t.Error("expected -o to not take -n as its value")
+	}
+	if errs := ParseErrors(); len(errs) != 1 || errs[0].Error() != "-o expects a value but was followed by -n" {
+		t.Errorf("expected a missing value error for -o, got %v", errs)
+	}
+}
+
+func TestTypedDefaults(t *testing.T) {
+	setArgs(t, "--count=5", "--bad=abc", "--ratio=0.5", "--verbose", "--color=maybe", "--cache=off", "--name=value")
+	Register(Argument{Name: "count", ExpectsValue: true})
+	Register(Argument{Name: "bad", ExpectsValue: true})
+	Register(Argument{Name: "ratio", ExpectsValue: true})
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "color", ExpectsValue: true})
+	Register(Argument{Name: "cache", ExpectsValue: true})
+	Register(Argument{Name: "name", ExpectsValue: true})
+	Register(Argument{Name: "unset", ExpectsValue: true})
+
+	if i := IntDefault("count", 1); i != 5 {
+		t.Errorf("expected present --count to be 5, got %d", i)
+	}
+	if i := IntDefault("bad", 1); i != 1 {
+		t.Errorf("expected invalid --bad to be the default, got %d", i)
+	}
+	if i := IntDefault("unset", 1); i != 1 {
+		t.Errorf("expected absent --unset to be the default, got %d", i)
+	}
+	if f := FloatDefault("ratio", 1); f != 0.5 {
+		t.Errorf("expected present --ratio to be 0.5, got %g", f)
+	}
+	if f := FloatDefault("bad", 1); f != 1 {
+		t.Errorf("expected invalid --bad to be the default, got %g", f)
+	}
+	if f := FloatDefault("unset", 1); f != 1 {
+		t.Errorf("expected absent --unset to be the default, got %g", f)
+	}
+	if !BoolDefault("verbose", false) {
+		t.Error("expected present --verbose to be true")
+	}
+	if !BoolDefault("color", true) || BoolDefault("color", false) {
+		t.Error("expected invalid --color to be the default")
+	}
+	if BoolDefault("cache", true) {
+		t.Error("expected present --cache=off to be false")
+	}
+	if !BoolDefault("unset", true) {
+		t.Error("expected absent --unset to be the default")
+	}
+	if s := StringDefault("name", "default"); s != "value" {
+		t.Errorf("expected present --name to be \"value\", got %q", s)
+	}
+	if s := StringDefault("unset", "default"); s != "default" {
+		t.Errorf("expected absent --unset to be the default, got %q", s)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "format", Description: "Output format", ExpectsValue: true, Values: []string{"json", "xml"}})
+	Register(Argument{Name: "verbose"})
+
+	var before = usage()
+	var args = Registered()
+	if len(args) != 2 || args[0].Name != "format" || args[1].Name != "verbose" {
+		t.Fatalf("expected registered arguments in order, got %+v", args)
+	}
+
+	args[0].Description = "Changed"
+	args[0].Values[0] = "yaml"
+	args[1] = Argument{Name: "changed"}
+	if after := usage(); after != before {
+		t.Errorf("expected usage to not change, got:\n%s", after)
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	setArgs(t, "--output=file.txt", "-v")
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "output", ExpectsValue: true, DefaultValue: "out.txt"})
+	Register(Argument{Name: "format", ExpectsValue: true, DefaultValue: "json"})
+	Register(Argument{Name: "color"})
+
+	var dump, err = DumpJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var expected = `{"color":{"value":false,"set":false},` +
+		`"format":{"value":"json","set":false},` +
+		`"output":{"value":"file.txt","set":true},` +
+		`"verbose":{"value":true,"set":true}}`
+	if string(dump) != expected {
+		t.Errorf("expected %s, got %s", expected, dump)
+	}
+
+	setArgs(t, "--port=80", "--ratio=0.5", "--debug=yes", "--timeout=30s", "--retries=many")
+	Register(Argument{Name: "port", ExpectsValue: true, Type: TypeInt})
+	Register(Argument{Name: "ratio", ExpectsValue: true, Type: TypeFloat})
+	Register(Argument{Name: "debug", ExpectsValue: true, Type: TypeBool})
+	Register(Argument{Name: "timeout", ExpectsValue: true, Type: TypeDuration})
+	Register(Argument{Name: "retries", ExpectsValue: true, Type: TypeInt})
+	Register(Argument{Name: "unset", ExpectsValue: true, Type: TypeInt})
+	if dump, err = DumpJSON(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = `{"debug":{"value":true,"set":true},` +
+		`"port":{"value":80,"set":true},` +
+		`"ratio":{"value":0.5,"set":true},` +
+		`"retries":{"value":"many","set":true},` +
+		`"timeout":{"value":"30s","set":true},` +
+		`"unset":{"value":"","set":false}}`
+	if string(dump) != expected {
+		t.Errorf("expected typed values %s, got %s", expected, dump)
+	}
+}
+
+func TestAbbrev(t *testing.T) {
+	AllowAbbrev = true
+	t.Cleanup(func() {
+		AllowAbbrev = false
+	})
+
+	setArgs(t, "--verb", "--out", "file.txt", "--ver")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "version"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+
+	if !Using("verbose") {
+		t.Error("expected --verb to be used as --verbose")
+	}
+	if Value("output") != "file.txt" {
+		t.Errorf("expected --out to have value \"file.txt\", got %q", Value("output"))
+	}
+	if Using("version") {
+		t.Error("expected ambiguous --ver to not be used as --version")
+	}
+	if errs := ParseErrors(); len(errs) != 1 || errs[0].Error() != "--ver is ambiguous, could be: --verbose, --version" {
+		t.Errorf("expected an ambiguous flag error, got %v", errs)
+	}
+	if unknown := UnknownFlags(); len(unknown) != 0 {
+		t.Errorf("expected abbreviations to not be unknown, got %v", unknown)
+	}
+}
+
+func TestSlice(t *testing.T) {
+	setArgs(t, "--tags= a, b,,c ", "--path=/bin:/usr/bin", "--escaped=a\\,b,c")
+	Register(Argument{Name: "tags", ExpectsValue: true})
+	Register(Argument{Name: "path", ExpectsValue: true, Separator: ":"})
+	Register(Argument{Name: "escaped", ExpectsValue: true})
+	Register(Argument{Name: "unset", ExpectsValue: true})
+
+	var tests = []struct {
+		name     string
+		expected []string
+	}{
+		{"tags", []string{"a", "b", "c"}},
+		{"path", []string{"/bin", "/usr/bin"}},
+		{"escaped", []string{"a,b", "c"}},
+		{"unset", []string{}},
+	}
+	for _, test := range tests {
+		if slice := Slice(test.name); fmt.Sprint(slice) != fmt.Sprint(test.expected) || len(slice) != len(test.expected) {
+			t.Errorf("expected --%s to be %q, got %q", test.name, test.expected, slice)
+		}
+	}
+}
+
+func TestNumericSlices(t *testing.T) {
+	setArgs(t, "--ports=80, 443,8080", "--ratios=0.5,1.5", "--malformed=80,abc")
+	Register(Argument{Name: "ports", ExpectsValue: true})
+	Register(Argument{Name: "ratios", ExpectsValue: true})
+	Register(Argument{Name: "malformed", ExpectsValue: true})
+	Register(Argument{Name: "unset", ExpectsValue: true})
+
+	if ports, err := IntSlice("ports"); err != nil || fmt.Sprint(ports) != "[80 443 8080]" {
+		t.Errorf("expected --ports to be [80 443 8080], got %v: %v", ports, err)
+	}
+	if ratios, err := Float64Slice("ratios"); err != nil || fmt.Sprint(ratios) != "[0.5 1.5]" {
+		t.Errorf("expected --ratios to be [0.5 1.5], got %v: %v", ratios, err)
+	}
+	if ints, err := IntSlice("unset"); err != nil || ints == nil || len(ints) != 0 {
+		t.Errorf("expected an empty slice for --unset, got %v: %v", ints, err)
+	}
+	if floats, err := Float64Slice("unset"); err != nil || floats == nil || len(floats) != 0 {
+		t.Errorf("expected an empty slice for --unset, got %v: %v", floats, err)
+	}
+
+	if _, err := IntSlice("malformed"); err == nil || !strings.HasPrefix(err.Error(), "--malformed element 1 expects an integer value, got \"abc\"") {
+		t.Errorf("expected an error for element 1 of --malformed, got %v", err)
+	}
+	if _, err := Float64Slice("malformed"); err == nil || !strings.HasPrefix(err.Error(), "--malformed element 1 expects a number value, got \"abc\"") {
+		t.Errorf("expected an error for element 1 of --malformed, got %v", err)
+	}
+}
+
+func TestStringMap(t *testing.T) {
+	setArgs(t, "--label", "env=prod", "--label=team=core", "--label", "malformed", "--label=env=dev", "--label==empty")
+	Register(Argument{Name: "label", ExpectsValue: true, Multiple: true})
+
+	var labels = StringMap("label")
+	var expected = map[string]string{"env": "dev", "team": "core"}
+	if fmt.Sprint(labels) != fmt.Sprint(expected) {
+		t.Errorf("expected labels %v, got %v", expected, labels)
+	}
+	if labels := StringMap("unset"); len(labels) != 0 {
+		t.Errorf("expected no labels, got %v", labels)
+	}
+}
+
+// TestConcurrentReads is meant to be run with -race.
+func TestConcurrentReads(t *testing.T) {
+	setArgs(t, "--output=file.txt", "-v", "--include=a", "--include=b", "--unknown", "positional")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "include", ExpectsValue: true, Multiple: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if Value("output") != "file.txt" || !Using("verbose") || len(Values("include")) != 2 || Count("v") != 1 {
+					t.Error("expected values to not change while being read")
+					return
+				}
+				if len(ParseErrors()) != 0 || len(Positional()) != 1 || len(UnknownFlags()) != 1 {
+					t.Error("expected parsed args to not change while being read")
+					return
+				}
+				if !Bool("verbose") || len(Slice("include")) != 1 || MustValue("output") != "file.txt" {
+					t.Error("expected values to not change while being read")
+					return
+				}
+				AllValuesWithUnknown()
+				DeprecationWarnings()
+				if _, err := DumpJSON(); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := Validate(); err != nil {
+					t.Error(err)
+					return
+				}
+				usage()
+			}
+		}()
+	}
+	var path = writeConfig(t, "config.json", `{"output": "config.txt"}`)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 10; j++ {
+			Parse([]string{"--output=file.txt", "-v", "--include=a", "--include=b", "--unknown", "positional"})
+			if err := LoadJSON(path); err != nil {
+				t.Error(err)
+			}
+			Example("mytool -v", "")
+			MutuallyExclusive("json", "yaml")
+			Requires("verbose", "output")
+		}
+	}()
+	wg.Wait()
+}
+
+func TestPrefixes(t *testing.T) {
+	var defaultPrefixes = Prefixes
+	Prefixes = []string{"/", "+"}
+	t.Cleanup(func() {
+		Prefixes = defaultPrefixes
+	})
+
+	setArgs(t, "+verbose", "/output", "file.txt", "+ab", "--not-a-flag", "+")
+	Register(Argument{Name: "verbose"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "all", Short: "a"})
+	Register(Argument{Name: "brief", Short: "b"})
+
+	if !Using("verbose") {
+		t.Error("expected +verbose to be used")
+	}
+	if Value("output") != "file.txt" {
+		t.Errorf("expected /output to have value \"file.txt\", got %q", Value("output"))
+	}
+	if !Using("all") || !Using("brief") {
+		t.Error("expected +ab to be stacked shorthand arguments")
+	}
+	if positional := Positional(); fmt.Sprint(positional) != "[--not-a-flag +]" {
+		t.Errorf("expected args without a prefix to be positional, got %q", positional)
+	}
+}
+
+func TestVisit(t *testing.T) {
+	setArgs(t, "-o", "file.txt", "--colour")
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "verbose", EnvVar: "ARGS_TEST_VISIT"})
+	Register(Argument{Name: "color", Aliases: []string{"colour"}})
+
+	var all []string
+	VisitAll(func(arg Argument) {
+		all = append(all, arg.Name)
+	})
+	if fmt.Sprint(all) != "[output verbose color]" {
+		t.Errorf("expected every registered argument to be visited, got %v", all)
+	}
+
+	t.Setenv("ARGS_TEST_VISIT", "true")
+	var visited []string
+	Visit(func(arg Argument, value string) {
+		visited = append(visited, arg.Name+"="+value)
+	})
+	if fmt.Sprint(visited) != "[output=file.txt color=]" {
+		t.Errorf("expected only passed arguments to be visited, got %v", visited)
+	}
+}
+
+func TestArgv(t *testing.T) {
+	var register = func() {
+		Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+		Register(Argument{Name: "verbose", Short: "v"})
+		Register(Argument{Name: "include", ExpectsValue: true, Multiple: true})
+		Register(Argument{Name: "color", Negatable: true, DefaultValue: "true"})
+		Register(Argument{Name: "unused"})
+	}
+
+	setArgs(t, "-o", "file.txt", "-v", "--include=a", "--include", "b", "--no-color", "positional")
+	register()
+
+	var argv = Argv()
+	if expected := "[--output=file.txt --verbose --include=a --include=b --no-color]"; fmt.Sprint(argv) != expected {
+		t.Errorf("expected argv %s, got %q", expected, argv)
+	}
+	if argv := Argv("include", "v", "unknown"); fmt.Sprint(argv) != "[--include=a --include=b --verbose]" {
+		t.Errorf("expected argv of given names, got %q", argv)
+	}
+
+	var before = fmt.Sprint(AllValues(), Values("include"))
+	setArgs(t, argv...)
+	register()
+	if after := fmt.Sprint(AllValues(), Values("include")); after != before {
+		t.Errorf("expected re-parsed argv to have values %s, got %s", before, after)
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	EnvPrefix = "ARGS_TEST_"
+	t.Cleanup(func() {
+		EnvPrefix = ""
+	})
+
+	setArgs(t, "--passed=flag")
+	Register(Argument{Name: "max-retries", ExpectsValue: true})
+	Register(Argument{Name: "token", ExpectsValue: true, EnvVar: "ARGS_TEST_EXPLICIT"})
+	Register(Argument{Name: "passed", ExpectsValue: true})
+	t.Setenv("ARGS_TEST_MAX_RETRIES", "3")
+	t.Setenv("ARGS_TEST_TOKEN", "prefixed")
+	t.Setenv("ARGS_TEST_EXPLICIT", "explicit")
+	t.Setenv("ARGS_TEST_PASSED", "env")
+
+	var tests = map[string]string{
+		"max-retries": "3",
+		"token":       "explicit",
+		"passed":      "flag",
+	}
+	for name, expected := range tests {
+		if value := Value(name); value != expected {
+			t.Errorf("expected --%s to have value %q, got %q", name, expected, value)
+		}
+	}
+	if !strings.Contains(usage(), "[env=ARGS_TEST_MAX_RETRIES]") {
+		t.Errorf("expected usage to show the prefixed environment variable, got:\n%s", usage())
+	}
+}
+
+func TestRequiresValue(t *testing.T) {
+	setArgs(t, "--output", "--empty=", "--default", "--given=x", "--given")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "empty", ExpectsValue: true})
+	Register(Argument{Name: "default", ExpectsValue: true, DefaultValue: "x"})
+	Register(Argument{Name: "given", ExpectsValue: true})
+
+	var err = Validate()
+	if err == nil || err.Error() != "--given requires a value\n--output requires a value" {
+		t.Errorf("expected flags without a value to be errors, got %v", err)
+	}
+
+	AllowEmptyValues = false
+	t.Cleanup(func() {
+		AllowEmptyValues = true
+	})
+	err = Validate()
+	if err == nil || err.Error() != "--empty requires a value\n--given requires a value\n--output requires a value" {
+		t.Errorf("expected empty values to be errors, got %v", err)
+	}
+
+	Parse([]string{"--output", "file.txt", "--given", "x"})
+	if err = Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestHidden(t *testing.T) {
+	setArgs(t, "--debug-trace=all", "-v")
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "debug-trace", ExpectsValue: true, Required: true, Values: []string{"all", "none"}, Hidden: true})
+
+	if !Using("debug-trace") || Value("debug-trace") != "all" || Count("debug-trace") != 1 {
+		t.Error("expected hidden --debug-trace to be used")
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	for name, output := range map[string]string{
+		"usage":           usage(),
+		"bash completion": BashCompletion("mytool"),
+		"zsh completion":  ZshCompletion("mytool"),
+		"man page":        ManPage("mytool", "1"),
+	} {
+		if strings.Contains(output, "debug-trace") {
+			t.Errorf("expected %s to not contain hidden --debug-trace, got:\n%s", name, output)
+		}
+	}
+
+	Parse([]string{"--debug-trace=some"})
+	if err := Validate(); err == nil {
+		t.Error("expected hidden --debug-trace to be validated")
+	}
+}
+
+func TestColorUsage(t *testing.T) {
+	ColorUsage = true
+	t.Cleanup(func() {
+		ColorUsage = false
+	})
+
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", Description: "Output file", ExpectsValue: true})
+	Register(Argument{Name: "verbose"})
+
+	var buf bytes.Buffer
+	if err := FprintUsage(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != usage() || strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("expected plain usage when not writing to a terminal, got:\n%q", buf.String())
+	}
+
+	var file, err = os.CreateTemp(t.TempDir(), "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if useColor(file) {
+		t.Error("expected no color when writing to a file")
+	}
+
+	var colored = formatUsage(true)
+	if !strings.Contains(colored, "\t\x1b[1m-o=  --output=\x1b[0m  Output file\n") {
+		t.Errorf("expected flags in bold, got:\n%q", colored)
+	}
+	if plain := strings.NewReplacer(bold, "", reset, "").Replace(colored); plain != usage() {
+		t.Errorf("expected colored usage without escape codes to be plain usage, got:\n%q", plain)
+	}
+}
+
+func TestUsageWidth(t *testing.T) {
+	UsageWidth = 40
+	t.Cleanup(func() {
+		UsageWidth = 0
+	})
+
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", Description: "The file that the output is written to, instead of stdout", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Description: "Print more"})
+
+	var expected = "Options:\n" +
+		"\t-o=  --output=  The file that\n" +
+		"\t                the output is\n" +
+		"\t                written to,\n" +
+		"\t                instead of\n" +
+		"\t                stdout\n" +
+		"\t     --verbose  Print more\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+
+	UsageWidth = 0
+	if !strings.Contains(usage(), "The file that the output is written to, instead of stdout\n") {
+		t.Errorf("expected descriptions to not be wrapped without a width, got:\n%s", usage())
+	}
+	t.Setenv("COLUMNS", "40")
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage wrapped to COLUMNS:\n%s\ngot:\n%s", expected, lines[1])
+	}
+}
+
+func TestRegisterAll(t *testing.T) {
+	setArgs(t, "-v", "--output=file.txt")
+	if err := RegisterAll(
+		Argument{Name: "verbose", Short: "v"},
+		Argument{Name: "output", ExpectsValue: true},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !Using("verbose") || Value("output") != "file.txt" {
+		t.Error("expected every argument to be registered")
+	}
+
+	var err = RegisterAll(
+		Argument{Name: "quiet", Short: "q"},
+		Argument{Name: "output"},
+		Argument{Name: "force", Short: "f"},
+	)
+	if err == nil || err.Error() != "argument 1: --output is already a registred argument" {
+		t.Errorf("expected a duplicate argument error, got %v", err)
+	}
+	if _, found := lookupArg("quiet"); !found {
+		t.Error("expected arguments before the duplicate to remain registered")
+	}
+	if _, found := lookupArg("force"); found {
+		t.Error("expected arguments after the duplicate to not be registered")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	setArgs(t, "--output", "file.txt", "-v")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Short: "v", Description: "Print more"})
+
+	if !Unregister("v") {
+		t.Fatal("expected -v to be unregistered")
+	}
+	if Unregister("verbose") {
+		t.Error("expected --verbose to already be unregistered")
+	}
+	if _, found := lookupArg("verbose"); found || strings.Contains(usage(), "verbose") {
+		t.Errorf("expected --verbose to be removed, got usage:\n%s", usage())
+	}
+	if Value("v") != "" || !Using("v") {
+		t.Error("expected -v to be an unknown flag")
+	}
+
+	Unregister("output")
+	if fmt.Sprint(Positional()) != "[file.txt]" {
+		t.Errorf("expected the value of --output to be positional, got %v", Positional())
+	}
+}
+
+func TestUsageHeaderFooter(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "verbose"})
+
+	var expected = "Options:\n\t--verbose\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage without a header or footer:\n%s\ngot:\n%s", expected, lines[1])
+	}
+
+	UsageHeader = "Build things."
+	UsageFooter = "See https://example.com for more."
+	t.Cleanup(func() {
+		UsageHeader = ""
+		UsageFooter = ""
+	})
+	expected = "Build things.\n\nOptions:\n\t--verbose\n\nSee https://example.com for more.\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage with a header and footer:\n%s\ngot:\n%s", expected, lines[1])
+	}
+}
+
+func TestExamples(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "release"})
+	Example("mytool build --release", "Build an optimized binary")
+	Example("mytool build", "Build a debug binary")
+	Example("mytool", "")
+
+	var expected = "Options:\n\t--release\n\n" +
+		"Examples:\n" +
+		"\tmytool build --release  # Build an optimized binary\n" +
+		"\tmytool build            # Build a debug binary\n" +
+		"\tmytool\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+}
+
+func TestMutuallyExclusive(t *testing.T) {
+	var tests = []struct {
+		argv     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"--json", "-q"}, ""},
+		{[]string{"--yaml", "-j"}, "cannot be used together: --json, --yaml"},
+		{[]string{"-v", "--quiet", "--xml", "--json", "--yaml"}, "cannot be used together: --json, --yaml, --xml\ncannot be used together: --verbose, --quiet"},
+	}
+	for _, test := range tests {
+		setArgs(t, test.argv...)
+		Register(Argument{Name: "json", Short: "j"})
+		Register(Argument{Name: "yaml"})
+		Register(Argument{Name: "xml"})
+		Register(Argument{Name: "verbose", Short: "v"})
+		Register(Argument{Name: "quiet", Short: "q"})
+		MutuallyExclusive("j", "yaml", "xml")
+		MutuallyExclusive("verbose", "quiet")
+
+		var err = Validate()
+		if (test.expected == "" && err != nil) || (test.expected != "" && (err == nil || err.Error() != test.expected)) {
+			t.Errorf("expected %v to have error %q, got %v", test.argv, test.expected, err)
+		}
+	}
+}
+
+func TestRequires(t *testing.T) {
+	var tests = []struct {
+		argv     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"--tls-key=key.pem"}, ""},
+		{[]string{"--tls-cert=cert.pem", "-k", "key.pem", "--tls-ca=ca.pem"}, ""},
+		{[]string{"--tls-cert=cert.pem"}, "--tls-cert requires --tls-key"},
+		{[]string{"--tls-cert=cert.pem", "--tls-key=key.pem", "--verify"}, "--verify requires --tls-ca"},
+	}
+	for _, test := range tests {
+		setArgs(t, test.argv...)
+		Register(Argument{Name: "tls-cert", ExpectsValue: true})
+		Register(Argument{Name: "tls-key", Short: "k", ExpectsValue: true})
+		Register(Argument{Name: "tls-ca", ExpectsValue: true})
+		Register(Argument{Name: "verify"})
+		Requires("tls-cert", "k")
+		Requires("verify", "tls-cert", "tls-ca")
+
+		var err = Validate()
+		if (test.expected == "" && err != nil) || (test.expected != "" && (err == nil || err.Error() != test.expected)) {
+			t.Errorf("expected %v to have error %q, got %v", test.argv, test.expected, err)
+		}
+	}
+}
+
+func TestValuesFallback(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "include", ExpectsValue: true, Multiple: true, DefaultValue: "x"})
+	Register(Argument{Name: "exclude", ExpectsValue: true, Multiple: true, EnvVar: "ARGS_TEST_EXCLUDE"})
+	Register(Argument{Name: "tags", ExpectsValue: true, Multiple: true})
+	t.Setenv("ARGS_TEST_EXCLUDE", "y")
+
+	if values := Values("include"); strings.Join(values, " ") != "x" {
+		t.Errorf("expected the default to be the only value, got %v", values)
+	}
+	if values := Values("exclude"); strings.Join(values, " ") != "y" {
+		t.Errorf("expected the EnvVar to be the only value, got %v", values)
+	}
+	if values := Values("tags"); len(values) != 0 {
+		t.Errorf("expected no values, got %v", values)
+	}
+	if value, exists := ValueAt("include", 0); !exists || value != "x" {
+		t.Errorf("expected the default at index 0, got %q", value)
+	}
+	if _, exists := ValueAt("include", 1); exists {
+		t.Error("expected no value at index 1")
+	}
+
+	Parse([]string{"--include=a", "--include=b"})
+	if values := Values("include"); strings.Join(values, " ") != "a b" {
+		t.Errorf("expected the passed values instead of the default, got %v", values)
+	}
+}
+
+func TestRequiresFallback(t *testing.T) {
+	setArgs(t, "--tls-cert=cert.pem")
+	Register(Argument{Name: "tls-cert", ExpectsValue: true})
+	Register(Argument{Name: "tls-key", ExpectsValue: true, EnvVar: "ARGS_TEST_TLS_KEY"})
+	Register(Argument{Name: "tls-ca", ExpectsValue: true})
+	Requires("tls-cert", "tls-key", "tls-ca")
+	t.Setenv("ARGS_TEST_TLS_KEY", "key.pem")
+
+	var err = Validate()
+	if err == nil || err.Error() != "--tls-cert requires --tls-ca" {
+		t.Errorf("expected --tls-key to be set by its EnvVar, got %v", err)
+	}
+
+	if err = LoadConfig(writeConfig(t, "config", "tls-ca=ca.pem")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = Validate(); err != nil {
+		t.Errorf("expected --tls-ca to be set by the config, got %v", err)
+	}
+}
+
+func TestValueAt(t *testing.T) {
+	setArgs(t, "--include=a", "-i", "b", "--output=file.txt")
+	Register(Argument{Name: "include", Short: "i", ExpectsValue: true, Multiple: true})
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "unset", ExpectsValue: true})
+
+	var tests = []struct {
+		name     string
+		i        int
+		expected string
+		exists   bool
+	}{
+		{"include", 0, "a", true},
+		{"i", 1, "b", true},
+		{"include", 2, "", false},
+		{"include", -1, "", false},
+		{"output", 0, "file.txt", true},
+		{"output", 1, "", false},
+		{"unset", 0, "", false},
+	}
+	for _, test := range tests {
+		if value, exists := ValueAt(test.name, test.i); value != test.expected || exists != test.exists {
+			t.Errorf("expected value %d of --%s to be %q %v, got %q %v", test.i, test.name, test.expected, test.exists, value, exists)
+		}
+	}
+}
+
+func TestExitOnError(t *testing.T) {
+	if argv := os.Getenv("ARGS_TEST_EXIT_ON_ERROR"); argv != "" {
+		ExitOnError = true
+		setArgs(t, strings.Fields(argv)...)
+		Register(Argument{Name: "port", ExpectsValue: true, Type: TypeInt})
+		Validate()
+		return
+	}
+
+	var tests = map[string]string{
+		"--port=abc": "--port expects an integer value",
+		"--unknown":  "unknown flags: --unknown",
+		"--=value":   "--=value is missing a flag name",
+	}
+	for argv, expected := range tests {
+		var cmd = exec.Command(os.Args[0], "-test.run=^TestExitOnError$")
+		cmd.Env = append(os.Environ(), "ARGS_TEST_EXIT_ON_ERROR="+argv)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		var err = cmd.Run()
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+			t.Errorf("expected %s to exit with a status of 2, got %v", argv, err)
+		}
+		if !strings.HasPrefix(stderr.String(), expected) || !strings.Contains(stderr.String(), "Options:\n") {
+			t.Errorf("expected %s to print %q and usage, got:\n%s", argv, expected, stderr.String())
+		}
+	}
+}
+
+// exitCode replaces Exit for the duration of the test, returning the code Exit is called with when fn is called.
+func exitCode(t *testing.T, fn func()) (code int) {
+	var exit = Exit
+	t.Cleanup(func() {
+		Exit = exit
+	})
+
+	type exited struct{}
+	code = -1
+	Exit = func(c int) {
+		code = c
+		panic(exited{})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(exited); !ok {
+				panic(r)
+			}
+		}
+	}()
+	fn()
+
+	return
+}
+
+func TestExit(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true})
+	if code := exitCode(t, func() { Validate() }); code != 1 {
+		t.Errorf("expected a missing argument to exit with a status of 1, got %d", code)
+	}
+
+	ExitOnError = true
+	t.Cleanup(func() {
+		ExitOnError = false
+	})
+	setArgs(t, "--unknown")
+	if code := exitCode(t, func() { Validate() }); code != 2 {
+		t.Errorf("expected an unknown flag to exit with a status of 2, got %d", code)
+	}
+	setArgs(t)
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true})
+	if code := exitCode(t, func() { Validate() }); code != 2 {
+		t.Errorf("expected a missing argument to exit with a status of 2 with ExitOnError, got %d", code)
+	}
+	setArgs(t)
+	if code := exitCode(t, func() { Validate() }); code != -1 {
+		t.Errorf("expected no errors to not exit, got %d", code)
+	}
+}
+
+func TestProgramDescription(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "verbose"})
+
+	ProgramDescription = "Builds things from source files."
+	UsageHeader = "Header"
+	t.Cleanup(func() {
+		ProgramDescription = ""
+		UsageHeader = ""
+	})
+	var expected = "Builds things from source files.\n\nHeader\n\nOptions:\n\t--verbose\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+
+	UsageWidth = 20
+	t.Cleanup(func() {
+		UsageWidth = 0
+	})
+	if lines := strings.SplitN(usage(), "\n", 3); lines[1] != "Builds things from" || lines[2][:13] != "source files." {
+		t.Errorf("expected the description to be wrapped, got:\n%s", usage())
+	}
+
+	ProgramDescription = ""
+	UsageHeader = ""
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != "Options:\n\t--verbose\n" {
+		t.Errorf("expected usage without a description, got:\n%s", lines[1])
+	}
+}
+
+func TestNumFlags(t *testing.T) {
+	var tests = []struct {
+		argv       []string
+		flags      int
+		positional int
+	}{
+		{nil, 0, 0},
+		{[]string{"-v", "--output=file.txt", "-v"}, 2, 0},
+		{[]string{"build", "./src"}, 0, 2},
+		{[]string{"build", "-v", "--", "-x"}, 1, 2},
+	}
+	for _, test := range tests {
+		setArgs(t, test.argv...)
+		if NumFlags() != test.flags || NumPositional() != test.positional {
+			t.Errorf("expected %v to have %d flags and %d positional args, got %d and %d", test.argv, test.flags, test.positional, NumFlags(), NumPositional())
+		}
+	}
+}
+
+func TestStrictDuplicates(t *testing.T) {
+	StrictDuplicates = true
+	t.Cleanup(func() {
+		StrictDuplicates = false
+	})
+
+	var tests = []struct {
+		argv     []string
+		expected string
+	}{
+		{[]string{"--output=a", "-v", "-v", "--include=a", "--include=b"}, ""},
+		{[]string{"--output=a", "--output", "b"}, "--output was passed more than once"},
+		{[]string{"-o", "a", "-o=b"}, "--output was passed more than once"},
+		{[]string{"--output=a", "-o", "b"}, "--output was passed more than once"},
+	}
+	for _, test := range tests {
+		setArgs(t, test.argv...)
+		Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+		Register(Argument{Name: "verbose", Short: "v"})
+		Register(Argument{Name: "include", ExpectsValue: true, Multiple: true})
+
+		var err = Validate()
+		if (test.expected == "" && err != nil) || (test.expected != "" && (err == nil || err.Error() != test.expected)) {
+			t.Errorf("expected %v to have error %q, got %v", test.argv, test.expected, err)
+		}
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	setArgs(t, "--files", "a.txt", "b.txt", "c.txt", "-v", "d.txt", "-f", "e.txt", "f.txt", "--", "-g.txt", "--files=g.txt", "h.txt")
+	Register(Argument{Name: "files", Short: "f", ExpectsValue: true, Multiple: true, Greedy: true})
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	if files := Values("files"); strings.Join(files, " ") != "a.txt b.txt c.txt e.txt f.txt" {
+		t.Errorf("expected greedy values until the next flag, got %v", files)
+	}
+	if positional := Positional(); strings.Join(positional, " ") != "d.txt -g.txt --files=g.txt h.txt" {
+		t.Errorf("expected args after a flag or -- to be positional, got %v", positional)
+	}
+
+	setArgs(t, "--files=a.txt", "b.txt", "c.txt", "-v", "d.txt", "-fe.txt", "f.txt")
+	Register(Argument{Name: "files", Short: "f", ExpectsValue: true, Multiple: true, Greedy: true})
+	Register(Argument{Name: "verbose", Short: "v"})
+	if files := Values("files"); strings.Join(files, " ") != "a.txt b.txt c.txt e.txt f.txt" || fmt.Sprint(Positional()) != "[d.txt]" {
+		t.Errorf("expected a value after = to be greedy, got %v", files)
+	}
+
+	if err := RegisterErr(Argument{Name: "greedy", ExpectsValue: true, Greedy: true}); err == nil {
+		t.Error("expected an error for a greedy argument that does not accept multiple values")
+	}
+}
+
+func TestHasAnyAll(t *testing.T) {
+	setArgs(t, "--json", "-v")
+	Register(Argument{Name: "json"})
+	Register(Argument{Name: "yaml"})
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	var tests = []struct {
+		names []string
+		any   bool
+		all   bool
+	}{
+		{nil, false, true},
+		{[]string{"json", "yaml"}, true, false},
+		{[]string{"json", "verbose"}, true, true},
+		{[]string{"yaml", "unknown"}, false, false},
+	}
+	for _, test := range tests {
+		if HasAny(test.names...) != test.any || HasAll(test.names...) != test.all {
+			t.Errorf("expected %v to have any %v and all %v, got %v and %v", test.names, test.any, test.all, HasAny(test.names...), HasAll(test.names...))
+		}
+	}
+}
+
+func TestShortBoolValue(t *testing.T) {
+	var tests = map[string]bool{
+		"-v":       true,
+		"-v=true":  true,
+		"-v=1":     true,
+		"-v=false": false,
+		"-v=0":     false,
+	}
+	for arg, expected := range tests {
+		setArgs(t, arg)
+		Register(Argument{Name: "verbose", Short: "v"})
+		if Bool("verbose") != expected || Bool("v") != expected {
+			t.Errorf("expected %s to be %v", arg, expected)
+		}
+	}
+}
+
+func TestValidator(t *testing.T) {
+	var port = func(value string) error {
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return errors.New("expected a port from 1 to 65535")
+		}
+		return nil
+	}
+	var calls int
+	var unset = func(value string) error {
+		calls++
+		return errors.New("should not be called")
+	}
+
+	setArgs(t, "--port=8080", "--admin-port=0", "--typed=abc")
+	Register(Argument{Name: "port", ExpectsValue: true, Validator: port})
+	Register(Argument{Name: "admin-port", ExpectsValue: true, Validator: port})
+	Register(Argument{Name: "default-port", ExpectsValue: true, DefaultValue: "99999", Validator: port})
+	Register(Argument{Name: "typed", ExpectsValue: true, Type: TypeInt, Validator: port})
+	Register(Argument{Name: "unset", ExpectsValue: true, Validator: unset})
+
+	var err = Validate()
+	var errs, ok = err.(Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", err)
+	}
+	if errs[0].Error() != "--admin-port: expected a port from 1 to 65535" || errs[1].Error() != "--default-port: expected a port from 1 to 65535" {
+		t.Errorf("expected validator errors naming the flag, got %v", errs)
+	}
+	if !strings.HasPrefix(errs[2].Error(), "--typed expects an integer value") {
+		t.Errorf("expected a type error instead of a validator error, got %v", errs[2])
+	}
+	if calls != 0 {
+		t.Error("expected the validator of an unset argument to not be called")
+	}
+
+	setArgs(t, "--ports=0", "--ports=8080")
+	Register(Argument{Name: "ports", ExpectsValue: true, Multiple: true, Validator: port})
+	if err = Validate(); err == nil || err.Error() != "--ports: expected a port from 1 to 65535" {
+		t.Errorf("expected every value of a multiple argument to be validated, got %v", err)
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	setOnMissing(t)
+	setArgs(t, "--format=yaml", "--port=abc", "--json", "--xml", "--count=1")
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true})
+	Register(Argument{Name: "port", ExpectsValue: true, Type: TypeInt})
+	Register(Argument{Name: "format", ExpectsValue: true, Values: []string{"json", "xml"}})
+	Register(Argument{Name: "xml"})
+	Register(Argument{Name: "json"})
+	Register(Argument{Name: "count", ExpectsValue: true})
+	MutuallyExclusive("xml", "json")
+	Requires("count", "port", "format", "output")
+
+	var expected = []string{
+		"--count requires --output",
+		"--format has invalid value \"yaml\", expected one of: json, xml",
+		"missing required argument: --output",
+		"--port expects an integer value: strconv.Atoi: parsing \"abc\": invalid syntax",
+		"cannot be used together: --xml, --json",
+	}
+	var err = Validate()
+	var errs, ok = err.(Errors)
+	if !ok || len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), err)
+	}
+	for i, message := range expected {
+		if errs[i].Error() != message {
+			t.Errorf("expected error %d to be %q, got %q", i, message, errs[i])
+		}
+	}
+}
+
+func TestExpectsValue(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	var tests = []struct {
+		name         string
+		expectsValue bool
+		found        bool
+	}{
+		{"output", true, true},
+		{"o", true, true},
+		{"verbose", false, true},
+		{"unknown", false, false},
+	}
+	for _, test := range tests {
+		if expectsValue, found := ExpectsValue(test.name); expectsValue != test.expectsValue || found != test.found {
+			t.Errorf("expected %s to expect value %v and be found %v, got %v and %v", test.name, test.expectsValue, test.found, expectsValue, found)
+		}
+	}
+}
+
+func TestShortOnly(t *testing.T) {
+	setArgs(t, "-n", "5", "-q")
+	Register(Argument{Short: "n", Description: "Number of lines", ExpectsValue: true, Type: TypeInt})
+	Register(Argument{Short: "q", Description: "Quiet"})
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	if !Using("n") || Value("n") != "5" || Count("n") != 1 {
+		t.Errorf("expected -n to be used with a value of 5, got %q", Value("n"))
+	}
+	if !Using("q") || Using("v") {
+		t.Error("expected only -q to be used")
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("expected no errors, got %s", err)
+	}
+	if flags := availableFlags(); flags != "-n= -q -v" {
+		t.Errorf("expected only shorthand flags, got %q", flags)
+	}
+
+	var expected = "Options:\n" +
+		"\t-n=<int>        Number of lines\n" +
+		"\t-q              Quiet\n" +
+		"\t-v   --verbose\n"
+	if lines := strings.SplitN(usage(), "\n", 2); lines[1] != expected {
+		t.Errorf("expected usage:\n%s\ngot:\n%s", expected, lines[1])
+	}
+
+	if err := RegisterErr(Argument{Description: "Nameless"}); err == nil || err.Error() != "argument does not have a name or shorthand" {
+		t.Errorf("expected an error for an argument without a name or shorthand, got %v", err)
+	}
+	if err := RegisterErr(Argument{Short: "q"}); err == nil || err.Error() != "-q is already a registred argument" {
+		t.Errorf("expected an error for a duplicate shorthand argument, got %v", err)
+	}
+	if err := RegisterErr(Argument{Short: "x", Negatable: true}); err == nil {
+		t.Error("expected an error for a negatable argument without a name")
+	}
+
+	setArgs(t, "-n", "abc")
+	Register(Argument{Short: "n", ExpectsValue: true, Type: TypeInt})
+	if err := Validate(); err == nil || !strings.HasPrefix(err.Error(), "-n expects an integer value") {
+		t.Errorf("expected an error for -n, got %v", err)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	setArgs(t, "--output=file.txt")
+	Register(Argument{Name: "log-level", Short: "l", ExpectsValue: true, DefaultValue: "info"})
+	Register(Argument{Name: "output", ExpectsValue: true, DefaultValue: "out.txt"})
+	Register(Argument{Name: "color", Negatable: true, DefaultValue: "true"})
+	Register(Argument{Name: "verbose"})
+
+	if err := SetDefault("l", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if value := Value("log-level"); value != "debug" {
+		t.Errorf("expected --log-level to default to debug, got %q", value)
+	}
+	if err := SetDefault("output", "default.txt"); err != nil || Value("output") != "file.txt" {
+		t.Errorf("expected the passed value of --output to take precedence, got %q: %v", Value("output"), err)
+	}
+	if err := SetDefault("color", "false"); err != nil || Bool("color") {
+		t.Errorf("expected --color to default to false: %v", err)
+	}
+
+	if err := SetDefault("verbose", "true"); err == nil || err.Error() != "--verbose does not expect value" {
+		t.Errorf("expected an error for an argument that does not expect value, got %v", err)
+	}
+	if err := SetDefault("unknown", "x"); err == nil || err.Error() != "--unknown is not a registered argument" {
+		t.Errorf("expected an error for an unregistered argument, got %v", err)
+	}
+}
+
+func TestUnusedFlags(t *testing.T) {
+	WarnDeprecated = false
+	t.Cleanup(func() { WarnDeprecated = true })
+
+	setArgs(t, "--output=file.txt", "-v", "--old")
+	Register(Argument{Name: "output", ExpectsValue: true, Required: true, Type: TypeString})
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "old", Deprecated: "use --new instead"})
+	Register(Argument{Name: "unset"})
+
+	if err := Validate(); err != nil {
+		t.Fatal(err)
+	}
+	DeprecationWarnings()
+	if unused := UnusedFlags(); fmt.Sprint(unused) != "[--output --verbose --old]" {
+		t.Errorf("expected every passed flag to be unused after Validate, got %v", unused)
+	}
+
+	AllValues()
+	AllValuesWithUnknown()
+	Visit(func(Argument, string) {})
+	if _, err := DumpJSON(); err != nil {
+		t.Fatal(err)
+	}
+	if unused := UnusedFlags(); fmt.Sprint(unused) != "[--output --verbose --old]" {
+		t.Errorf("expected every passed flag to be unused after logging every value, got %v", unused)
+	}
+
+	Value("output")
+	Count("old")
+	if unused := UnusedFlags(); fmt.Sprint(unused) != "[--verbose]" {
+		t.Errorf("expected only --verbose to be unused, got %v", unused)
+	}
+
+	Bool("v")
+	if unused := UnusedFlags(); len(unused) != 0 {
+		t.Errorf("expected no unused flags, got %v", unused)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", ExpectsValue: true, DefaultValue: "a.txt", Aliases: []string{"out"}, EnvVar: "OUTPUT", Required: true, Deprecated: "use --file"})
+	Register(Argument{Name: "group", Group: "Extra"})
+	Example("mytool -o b.txt", "")
+	Command("build")
+
+	var labels = Strings
+	t.Cleanup(func() { Strings = labels })
+	Strings = UsageStrings{
+		Usage:      "UTILISATION",
+		Options:    "Options générales",
+		Commands:   "Commandes",
+		Examples:   "Exemples",
+		Default:    "défaut",
+		Aliases:    "alias",
+		Env:        "env",
+		Required:   "obligatoire",
+		Deprecated: "obsolète",
+	}
+
+	var name = programName()
+	var expected = "UTILISATION: " + name + "  [-o= --group]\n" +
+		"Commandes:\n\tbuild\n\n" +
+		"Options générales:\n\t-o=  --output=  [défaut=a.txt] [alias=--out] [env=OUTPUT] [obligatoire] [obsolète]\n" +
+		"\nExtra:\n\t     --group\n" +
+		"\nExemples:\n\tmytool -o b.txt\n"
+	if u := usage(); u != expected {
+		t.Errorf("expected translated usage:\n%s\ngot:\n%s", expected, u)
+	}
+
+	var buf bytes.Buffer
+	if err := PrintUsageTo(&buf, DefaultUsageTemplate); err != nil || buf.String() != expected {
+		t.Errorf("expected translated usage from DefaultUsageTemplate:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestParsed(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	if Parsed() {
+		t.Error("expected Parsed to be false after Reset")
+	}
+
+	Parse([]string{"--output", "file.txt"})
+	if !Parsed() {
+		t.Error("expected Parsed to be true after Parse")
+	}
+	if Value("output") != "" || strings.Join(Positional(), " ") != "file.txt" {
+		t.Errorf("expected file.txt to be positional before --output is registered, got %q", Value("output"))
+	}
+
+	Register(Argument{Name: "output", ExpectsValue: true})
+	if Value("output") != "file.txt" || len(Positional()) != 0 {
+		t.Errorf("expected file.txt to be the value of --output once it is registered, got %q", Value("output"))
+	}
+
+	Parse([]string{"--output", "other.txt", "input.txt"})
+	if Value("output") != "other.txt" || strings.Join(Positional(), " ") != "input.txt" {
+		t.Errorf("expected other.txt to be the value of --output, got %q", Value("output"))
+	}
+
+	if err := Reparse(); err != nil {
+		t.Errorf("expected no error from Reparse, got %v", err)
+	}
+
+	Reset()
+	Register(Argument{Name: "output", ExpectsValue: true})
+	if !Parsed() {
+		t.Error("expected Parsed to be true after args are parsed against a registered argument")
+	}
+
+	Parse([]string{"--=value", "--output", "file.txt"})
+	var err = Reparse()
+	if err == nil || err.Error() != ParseErrors()[0].Error() {
+		t.Errorf("expected Reparse to return the parse errors, got %v", err)
+	}
+	if Value("output") != "file.txt" {
+		t.Errorf("expected file.txt to be the value of --output after Reparse, got %q", Value("output"))
+	}
+}
+
+func TestShorts(t *testing.T) {
+	setArgs(t, "-?", "-qo", "file.txt")
+	Register(Argument{Name: "help", Short: "h", Shorts: []string{"?"}, Description: "Print help"})
+	Register(Argument{Name: "output", Short: "o", Shorts: []string{"O"}, ExpectsValue: true})
+	Register(Argument{Name: "quiet", Short: "q"})
+
+	if !Using("help") || !Using("h") || !Using("?") || Count("help") != 1 {
+		t.Error("expected --help to be used by -?")
+	}
+	if Value("output") != "file.txt" || Value("O") != "file.txt" || !Using("quiet") {
+		t.Errorf("expected stacked shorthand arguments to be split, got %q", Value("output"))
+	}
+
+	setArgs(t, "-h", "-Oout.txt")
+	Register(Argument{Name: "help", Short: "h", Shorts: []string{"?"}, Description: "Print help"})
+	Register(Argument{Name: "output", Short: "o", Shorts: []string{"O"}, ExpectsValue: true})
+	if !Using("?") || Value("o") != "out.txt" {
+		t.Errorf("expected every shorthand argument to resolve to the same argument, got %q", Value("o"))
+	}
+	if details := usageDetails(Registered()[0]); details != "Print help [aliases=-?]" {
+		t.Errorf("expected -? to be listed as an alias, got %q", details)
+	}
+
+	var tests = []struct {
+		arg      Argument
+		expected string
+	}{
+		{Argument{Name: "query", Shorts: []string{"?"}}, "-? is already a registred shorthand argument"},
+		{Argument{Name: "other", Short: "O"}, "-O is already a registred shorthand argument"},
+		{Argument{Name: "long", Shorts: []string{"ab"}}, "-ab is not a single character shorthand argument"},
+		{Argument{Name: "alias", Aliases: []string{"?"}}, "--alias alias ? is already registered by --help"},
+	}
+	for _, test := range tests {
+		if err := RegisterErr(test.arg); err == nil || err.Error() != test.expected {
+			t.Errorf("expected %q, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestTrimValues(t *testing.T) {
+	t.Cleanup(func() { TrimValues = false })
+	t.Setenv("TEAM", "  core\t")
+	var path = writeConfig(t, "config.json", `{"role": " admin "}`)
+
+	var tests = []struct {
+		trim     bool
+		expected []string
+	}{
+		{false, []string{" bob ", "  core\t", " admin "}},
+		{true, []string{"bob", "core", "admin"}},
+	}
+	for _, test := range tests {
+		TrimValues = test.trim
+		setArgs(t, "--name= bob ")
+		Register(Argument{Name: "name", ExpectsValue: true})
+		Register(Argument{Name: "team", ExpectsValue: true, EnvVar: "TEAM"})
+		Register(Argument{Name: "role", ExpectsValue: true})
+		if err := LoadJSON(path); err != nil {
+			t.Fatal(err)
+		}
+
+		var values = []string{Value("name"), Value("team"), Value("role")}
+		if fmt.Sprintf("%q", values) != fmt.Sprintf("%q", test.expected) {
+			t.Errorf("TrimValues=%v: expected %q, got %q", test.trim, test.expected, values)
+		}
+		if Args["name"] != test.expected[0] {
+			t.Errorf("TrimValues=%v: expected Args to have %q, got %q", test.trim, test.expected[0], Args["name"])
+		}
+	}
+}
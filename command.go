@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CommandSet is a subcommand with its own set of arguments.
+// The arguments of a CommandSet are only registered once it is selected by Dispatch.
+type CommandSet struct {
+	Name        string
+	Description string
+	arguments   []Argument
+}
+
+// commands are the CommandSets created with Command, in the order they were created.
+var commands []*CommandSet
+
+// dispatched is the CommandSet selected by Dispatch.
+var dispatched *CommandSet
+
+// Command returns the CommandSet for the subcommand name, creating it if it does not exist.
+func Command(name string) *CommandSet {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	var command = &CommandSet{Name: name}
+	commands = append(commands, command)
+
+	return command
+}
+
+// Register an Argument for the subcommand.
+// The Argument is registered once the subcommand is selected by Dispatch, or immediately if it already was.
+func (c *CommandSet) Register(arg Argument) {
+	mutex.Lock()
+	c.arguments = append(c.arguments, arg)
+	var selected = dispatched == c
+	mutex.Unlock()
+
+	if selected {
+		Register(arg)
+	}
+}
+
+// Using returns a boolean indicating if an Argument's Name was passed to the subcommand.
+// Using always returns false if the subcommand was not selected by Dispatch.
+func (c *CommandSet) Using(name string) bool {
+	return c.selected() && Using(name)
+}
+
+// Value returns the value of an Argument passed to the subcommand.
+// Value always returns an empty string if the subcommand was not selected by Dispatch.
+func (c *CommandSet) Value(name string) string {
+	if !c.selected() {
+		return ""
+	}
+
+	return Value(name)
+}
+
+// selected reports whether c is the CommandSet selected by Dispatch.
+func (c *CommandSet) selected() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return dispatched == c
+}
+
+// Dispatch selects the CommandSet named by the first positional arg, registers its arguments,
+// and parses the args again without the name of the subcommand. Dispatch returns the name of the subcommand,
+// or an error if no subcommand was passed or it is not a subcommand created with Command.
+// Positional args after a standalone "--" are operands, so they are never the name of a subcommand.
+func Dispatch() (string, error) {
+	mutex.Lock()
+	if firstPositional == -1 || terminated(parsedArgs[:firstPositional]) {
+		var err = fmt.Errorf("expected a command: %s", commandNames())
+		mutex.Unlock()
+		return "", err
+	}
+
+	var name = parsedArgs[firstPositional]
+	var command *CommandSet
+	for _, c := range commands {
+		if c.Name == name {
+			command = c
+		}
+	}
+	if command == nil {
+		var err = fmt.Errorf("unknown command \"%s\", expected one of: %s", name, commandNames())
+		mutex.Unlock()
+		return "", err
+	}
+
+	dispatched = command
+	var arguments = append([]Argument(nil), command.arguments...)
+	var argv = append([]string{}, parsedArgs[:firstPositional]...)
+	argv = append(argv, parsedArgs[firstPositional+1:]...)
+	mutex.Unlock()
+
+	for _, arg := range arguments {
+		if err := RegisterErr(arg); err != nil {
+			return name, err
+		}
+	}
+	parse(argv)
+
+	return name, nil
+}
+
+// terminated determines if argv includes a standalone "--".
+func terminated(argv []string) bool {
+	for _, a := range argv {
+		if a == "--" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// commandNames returns the names of the commands created with Command, separated by commas.
+func commandNames() string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// commandsUsage generates the list of commands printed in the usage message.
+func commandsUsage() (usage string) {
+	if len(commands) == 0 {
+		return
+	}
+
+	var nameWidth int
+	for _, c := range commands {
+		if width := utf8.RuneCountInString(c.Name); width > nameWidth {
+			nameWidth = width
+		}
+	}
+
+	usage = Strings.Commands + ":\n"
+	for _, c := range commands {
+		var commandUsage = "\t" + c.Name
+		if c.Description != "" {
+			commandUsage += strings.Repeat(" ", nameWidth-utf8.RuneCountInString(c.Name)) + "  " + c.Description
+		}
+		usage += commandUsage + "\n"
+	}
+
+	return usage + "\n"
+}
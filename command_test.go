@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDispatch(t *testing.T) {
+	setArgs(t, "-v", "build", "--output", "a.out", "main.go")
+	Register(Argument{Name: "verbose", Short: "v"})
+
+	var build = Command("build")
+	build.Description = "Build a binary"
+	build.Register(Argument{Name: "output", ExpectsValue: true})
+	var deploy = Command("deploy")
+	deploy.Register(Argument{Name: "target", ExpectsValue: true})
+
+	var name, err = Dispatch()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name != "build" {
+		t.Errorf("expected build to be dispatched, got %q", name)
+	}
+	if !build.Using("output") || build.Value("output") != "a.out" {
+		t.Errorf("expected build --output to have value \"a.out\", got %q", build.Value("output"))
+	}
+	if !Using("verbose") {
+		t.Error("expected -v before the command to be used")
+	}
+	if deploy.Using("target") {
+		t.Error("expected deploy to not be dispatched")
+	}
+	if _, found := lookupArg("target"); found {
+		t.Error("expected the arguments of deploy to not be registered")
+	}
+	if positional := Positional(); len(positional) != 1 || positional[0] != "main.go" {
+		t.Errorf("expected the command to be removed from positional args, got %v", positional)
+	}
+
+	if usage := usage(); !strings.Contains(usage, "Commands:\n\tbuild   Build a binary\n\tdeploy\n") {
+		t.Errorf("expected usage to list commands, got:\n%s", usage)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	setArgs(t, "--verbose")
+	Command("build")
+	Command("deploy")
+	if _, err := Dispatch(); err == nil || err.Error() != "expected a command: build, deploy" {
+		t.Errorf("expected missing command error, got %v", err)
+	}
+
+	Parse([]string{"test"})
+	if _, err := Dispatch(); err == nil || err.Error() != "unknown command \"test\", expected one of: build, deploy" {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+
+	Parse([]string{"--", "build"})
+	if _, err := Dispatch(); err == nil || err.Error() != "expected a command: build, deploy" {
+		t.Errorf("expected an operand after -- to not be a command, got %v", err)
+	}
+}
+
+func TestCommandsUsageWidth(t *testing.T) {
+	setArgs(t)
+	Command("über").Description = "Download"
+	Command("up").Description = "Upload"
+	if usage := commandsUsage(); usage != "Commands:\n\tüber  Download\n\tup    Upload\n\n" {
+		t.Errorf("expected commands to be aligned by character, got:\n%s", usage)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// BashCompletion generates a bash completion script for progName based on the arguments you have registered.
+// All flags are offered as completions, and the Values of a flag are offered as completions after it.
+func BashCompletion(progName string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var funcName = "_" + nonIdentifierChars.ReplaceAllString(progName, "_") + "_completion"
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "# bash completion for %s\n", progName)
+	fmt.Fprintf(&script, "%s() {\n", funcName)
+	script.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	script.WriteString("\tlocal prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	script.WriteString("\tif [[ \"${cur}\" == \"=\" ]]; then\n")
+	script.WriteString("\t\tcur=\"\"\n")
+	script.WriteString("\telif [[ \"${prev}\" == \"=\" ]]; then\n")
+	script.WriteString("\t\tprev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	script.WriteString("\tfi\n\n")
+
+	script.WriteString("\tcase \"${prev}\" in\n")
+	for _, arg := range registered {
+		if len(arg.Values) == 0 || arg.Hidden {
+			continue
+		}
+		var patterns = arg.flag()
+		if arg.Name != "" && arg.Short != "" {
+			patterns += "|-" + arg.Short
+		}
+		fmt.Fprintf(&script, "\t%s)\n", patterns)
+		fmt.Fprintf(&script, "\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n", strings.Join(arg.Values, " "))
+		script.WriteString("\t\treturn 0\n")
+		script.WriteString("\t\t;;\n")
+	}
+	script.WriteString("\tesac\n\n")
+
+	var flags []string
+	for _, arg := range registered {
+		if !arg.Hidden {
+			flags = append(flags, arg.flag())
+		}
+	}
+	fmt.Fprintf(&script, "\tCOMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n", strings.Join(flags, " "))
+	script.WriteString("}\n")
+	fmt.Fprintf(&script, "complete -F %s %s\n", funcName, progName)
+
+	return script.String()
+}
+
+// ZshCompletion generates a zsh completion script for progName based on the arguments you have registered.
+// Each flag is offered with its Description, and the Values of a flag are offered as completions for its value.
+func ZshCompletion(progName string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var funcName = "_" + nonIdentifierChars.ReplaceAllString(progName, "_")
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "#compdef %s\n\n", progName)
+	fmt.Fprintf(&script, "%s() {\n", funcName)
+	script.WriteString("\t_arguments")
+	for _, arg := range registered {
+		if !arg.Hidden {
+			script.WriteString(" \\\n\t\t" + zshArgSpec(arg))
+		}
+	}
+	script.WriteString("\n}\n\n")
+	fmt.Fprintf(&script, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n", funcName)
+	fmt.Fprintf(&script, "\t%s \"$@\"\n", funcName)
+	script.WriteString("else\n")
+	fmt.Fprintf(&script, "\tcompdef %s %s\n", funcName, progName)
+	script.WriteString("fi\n")
+
+	return script.String()
+}
+
+// zshArgSpec generates the _arguments spec for arg (e.g. '(-a --arg)'{-a=,--arg=}'[Description]:arg:(a b)').
+func zshArgSpec(arg Argument) (spec string) {
+	var suffix string
+	if arg.ExpectsValue {
+		suffix = "="
+	}
+
+	if arg.Name != "" && arg.Short != "" {
+		spec = fmt.Sprintf("'(-%s --%s)'{-%s%s,--%s%s}'", arg.Short, arg.Name, arg.Short, suffix, arg.Name, suffix)
+	} else {
+		spec = fmt.Sprintf("'%s%s", arg.flag(), suffix)
+	}
+
+	spec += "[" + zshEscape(arg.Description) + "]"
+
+	if arg.ExpectsValue {
+		spec += ":" + arg.key() + ":"
+		if len(arg.Values) != 0 {
+			var values []string
+			for _, v := range arg.Values {
+				values = append(values, strings.ReplaceAll(zshEscape(v), " ", "\\ "))
+			}
+			spec += "(" + strings.Join(values, " ") + ")"
+		}
+	}
+
+	return spec + "'"
+}
+
+// zshEscape escapes s to be used within a single-quoted _arguments spec.
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
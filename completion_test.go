@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBashCompletion(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "format", Short: "f", ExpectsValue: true, Values: []string{"json", "xml"}})
+	Register(Argument{Name: "verbose", Short: "v"})
+	Register(Argument{Name: "output", ExpectsValue: true})
+
+	var script = BashCompletion("my-tool")
+	for _, expected := range []string{
+		"_my_tool_completion() {",
+		"--format|-f)",
+		"compgen -W \"json xml\"",
+		"compgen -W \"--format --verbose --output\"",
+		"complete -F _my_tool_completion my-tool",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected bash completion to contain %q, got:\n%s", expected, script)
+		}
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "format", Short: "f", Description: "Output format", ExpectsValue: true, Values: []string{"json", "xml"}})
+	Register(Argument{Name: "verbose", Short: "v", Description: "Print [more] details"})
+	Register(Argument{Name: "output", Description: "Output file", ExpectsValue: true})
+
+	var script = ZshCompletion("my-tool")
+	for _, expected := range []string{
+		"#compdef my-tool",
+		"_my_tool() {",
+		`'(-f --format)'{-f=,--format=}'[Output format]:format:(json xml)'`,
+		`'(-v --verbose)'{-v,--verbose}'[Print \[more\] details]'`,
+		`'--output=[Output file]:output:'`,
+		"compdef _my_tool my-tool",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected zsh completion to contain %q, got:\n%s", expected, script)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// config are the values loaded from config files by the key of the Argument they refer to (its Name, or its Short if it does not have a Name).
+var config = make(map[string]string)
+
+// LoadConfig loads a config file of key=value pairs, one per line, as a fallback for arguments that were not passed.
+// Keys are the names of registered arguments. Blank lines and lines beginning with # are skipped.
+// Values loaded from a config take precedence over an Argument's EnvVar and DefaultValue.
+// A warning is printed to stderr for keys that are not registered arguments.
+func LoadConfig(path string) error {
+	var file, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var scanner = bufio.NewScanner(file)
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var keyValue = strings.SplitN(line, "=", 2)
+		if len(keyValue) != 2 {
+			return fmt.Errorf("%s:%d: expected key=value", path, lineNumber)
+		}
+
+		var key = strings.TrimSpace(keyValue[0])
+		var arg, found = lookupArg(key)
+		if !found {
+			fmt.Fprintf(os.Stderr, "%s:%d: unknown argument %s\n", path, lineNumber, key)
+			continue
+		}
+		config[arg.key()] = strings.TrimSpace(keyValue[1])
+	}
+
+	return scanner.Err()
+}
+
+// LoadJSON loads a config file of a flat JSON object as a fallback for arguments that were not passed.
+// Keys are the names of registered arguments, values must be a string, number or boolean.
+// Numbers and booleans are converted to strings as they are written (e.g. 1.5 or true).
+// Values loaded from a config take precedence over an Argument's EnvVar and DefaultValue.
+// A warning is printed to stderr for keys that are not registered arguments.
+func LoadJSON(path string) error {
+	var file, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var values map[string]interface{}
+	var decoder = json.NewDecoder(file)
+	decoder.UseNumber()
+	if err = decoder.Decode(&values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var stringValues = make(map[string]string)
+	for _, key := range keys {
+		switch v := values[key].(type) {
+		case string:
+			stringValues[key] = v
+		case json.Number:
+			stringValues[key] = v.String()
+		case bool:
+			stringValues[key] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("%s: %s must be a string, number or boolean", path, key)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, key := range keys {
+		var arg, found = lookupArg(key)
+		if !found {
+			fmt.Fprintf(os.Stderr, "%s: unknown argument %s\n", path, key)
+			continue
+		}
+		config[arg.key()] = stringValues[key]
+	}
+
+	return nil
+}
+
+// dotenv are the values loaded from .env files by the key of the Argument they refer to (its Name, or its Short if it does not have a Name).
+var dotenv = make(map[string]string)
+
+// RequireDotenv makes LoadDotenv return an error if the file does not exist, instead of loading nothing.
+var RequireDotenv bool
+
+// LoadDotenv loads a .env file of KEY=value pairs, one per line, as a fallback for environment variables.
+// Keys refer to a registered Argument by its Name, case-insensitively with underscores as dashes (e.g. MAX_RETRIES for --max-retries),
+// or by its EnvVar. Keys that do not refer to a registered Argument are skipped.
+// Lines can begin with export, and values can be quoted with single or double quotes.
+// Blank lines and lines beginning with # are skipped, as is the rest of a line after a # outside of quotes.
+// Environment variables that are set take precedence over values loaded from a .env file.
+// If the file does not exist, nothing is loaded, unless RequireDotenv is true.
+func LoadDotenv(path string) error {
+	var file, err = os.Open(path)
+	if os.IsNotExist(err) && !RequireDotenv {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var scanner = bufio.NewScanner(file)
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		var key, value, found = strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected KEY=value", path, lineNumber)
+		}
+		if value, err = dotenvValue(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if arg, found := dotenvArg(strings.TrimSpace(key)); found {
+			dotenv[arg.key()] = value
+		}
+	}
+
+	return scanner.Err()
+}
+
+// dotenvArg returns the registered Argument that a key in a .env file refers to.
+func dotenvArg(key string) (Argument, bool) {
+	var name = strings.ReplaceAll(key, "_", "-")
+	for _, r := range registered {
+		if (r.Name != "" && strings.EqualFold(r.Name, name)) || (r.envVar() != "" && r.envVar() == key) {
+			return r, true
+		}
+	}
+
+	return Argument{}, false
+}
+
+// dotenvValue returns a value in a .env file without its quotes or comment.
+// Escaped newlines, quotes and backslashes in double quotes are unescaped.
+func dotenvValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if comment := strings.Index(value, " #"); comment != -1 {
+			value = value[:comment]
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	var quote = rune(value[0])
+	var unquoted strings.Builder
+	var escaped bool
+	for _, c := range value[1:] {
+		switch {
+		case escaped:
+			switch c {
+			case 'n':
+				unquoted.WriteRune('\n')
+			case '"', '\\':
+				unquoted.WriteRune(c)
+			default:
+				unquoted.WriteRune('\\')
+				unquoted.WriteRune(c)
+			}
+			escaped = false
+		case c == '\\' && quote == '"':
+			escaped = true
+		case c == quote:
+			return unquoted.String(), nil
+		default:
+			unquoted.WriteRune(c)
+		}
+	}
+
+	return "", fmt.Errorf("%c quote is not closed", quote)
+}
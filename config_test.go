@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes contents to a file in a temporary directory and returns its path.
+func writeConfig(t *testing.T, name string, contents string) string {
+	var path = filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	setArgs(t, "--output=cli.txt")
+	Register(Argument{Name: "output", ExpectsValue: true})
+	Register(Argument{Name: "format", ExpectsValue: true, EnvVar: "ARGS_TEST_FORMAT"})
+	Register(Argument{Name: "level", ExpectsValue: true, DefaultValue: "info"})
+	Register(Argument{Name: "user", ExpectsValue: true, EnvVar: "ARGS_TEST_USER", DefaultValue: "nobody"})
+	t.Setenv("ARGS_TEST_FORMAT", "xml")
+	t.Setenv("ARGS_TEST_USER", "env")
+
+	var path = writeConfig(t, "config", `
+# comment
+output = config.txt
+format=json
+#level=debug
+unknown=value
+`)
+	if err := LoadConfig(path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = map[string]string{
+		"output": "cli.txt",
+		"format": "json",
+		"level":  "info",
+		"user":   "env",
+	}
+	for name, expected := range tests {
+		if Value(name) != expected {
+			t.Errorf("--%s: expected %q, got %q", name, expected, Value(name))
+		}
+	}
+	if !Using("format") {
+		t.Error("expected --format to be used from the config")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	setArgs(t)
+	if err := LoadConfig(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if err := LoadConfig(writeConfig(t, "config", "output\n")); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	setArgs(t, "--name=cli")
+	Register(Argument{Name: "name", ExpectsValue: true})
+	Register(Argument{Name: "verbose", ExpectsValue: true})
+	Register(Argument{Name: "count", ExpectsValue: true})
+	Register(Argument{Name: "ratio", ExpectsValue: true})
+
+	var path = writeConfig(t, "config.json", `{"name": "json", "verbose": true, "count": 3, "ratio": 1.5, "unknown": "value"}`)
+	if err := LoadJSON(path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if Value("name") != "cli" {
+		t.Errorf("expected --name to take precedence over the config, got %q", Value("name"))
+	}
+	if !Bool("verbose") {
+		t.Error("expected --verbose to be true")
+	}
+	if i, err := Int("count"); err != nil || i != 3 {
+		t.Errorf("expected --count to be 3, got %d, %v", i, err)
+	}
+	if f, err := Float("ratio"); err != nil || f != 1.5 {
+		t.Errorf("expected --ratio to be 1.5, got %g, %v", f, err)
+	}
+}
+
+func TestLoadJSONErrors(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "name", ExpectsValue: true})
+	if err := LoadJSON(writeConfig(t, "malformed.json", `{"name": `)); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+	if err := LoadJSON(writeConfig(t, "nested.json", `{"name": {"first": "a"}}`)); err == nil {
+		t.Error("expected an error for a nested object")
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	EnvPrefix = "ARGS_TEST_"
+	t.Cleanup(func() {
+		EnvPrefix = ""
+	})
+
+	setArgs(t, "--passed=cli")
+	Register(Argument{Name: "max-retries", ExpectsValue: true})
+	Register(Argument{Name: "message", ExpectsValue: true})
+	Register(Argument{Name: "path", ExpectsValue: true})
+	Register(Argument{Name: "token", ExpectsValue: true, EnvVar: "MY_TOKEN"})
+	Register(Argument{Name: "prefixed", ExpectsValue: true})
+	Register(Argument{Name: "user", ExpectsValue: true})
+	Register(Argument{Name: "passed", ExpectsValue: true})
+	t.Setenv("ARGS_TEST_USER", "env")
+
+	var path = writeConfig(t, ".env", `
+# comment
+export MAX_RETRIES=3 # inline comment
+message="hello \"world\"\nbye" # comment
+Path='C:\dir #1'
+MY_TOKEN = secret
+ARGS_TEST_PREFIXED=prefix
+USER=dotenv
+PASSED=dotenv
+UNKNOWN=value
+`)
+	if err := LoadDotenv(path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = map[string]string{
+		"max-retries": "3",
+		"message":     "hello \"world\"\nbye",
+		"path":        "C:\\dir #1",
+		"token":       "secret",
+		"prefixed":    "prefix",
+		"user":        "env",
+		"passed":      "cli",
+	}
+	for name, expected := range tests {
+		if Value(name) != expected {
+			t.Errorf("--%s: expected %q, got %q", name, expected, Value(name))
+		}
+	}
+}
+
+func TestLoadDotenvErrors(t *testing.T) {
+	setArgs(t)
+	var missing = filepath.Join(t.TempDir(), ".env")
+	if err := LoadDotenv(missing); err != nil {
+		t.Errorf("expected a missing .env file to be skipped, got %v", err)
+	}
+
+	RequireDotenv = true
+	t.Cleanup(func() {
+		RequireDotenv = false
+	})
+	if err := LoadDotenv(missing); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	if err := LoadDotenv(writeConfig(t, ".env", "KEY\n")); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+	if err := LoadDotenv(writeConfig(t, ".env", "KEY=\"unclosed\n")); err == nil || !strings.HasSuffix(err.Error(), ":1: \" quote is not closed") {
+		t.Errorf("expected an unclosed quote error, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ParseInto registers an Argument for each field of the struct that dst points to with an arg tag,
+// then sets each field to the value of its Argument.
+// The arg tag is the Name of the Argument, optionally followed by its Short (e.g. `arg:"port,short=p"`).
+// The default and description tags are its DefaultValue and Description (e.g. `default:"8080"`).
+// Fields can be a string, int, float64, bool, time.Duration or []string, which accepts multiple values.
+// A bool field with a default is Negatable.
+func ParseInto(dst interface{}) error {
+	var v = reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+
+	var fields []int
+	var args []Argument
+	for i := 0; i < v.NumField(); i++ {
+		var field = v.Type().Field(i)
+		var tag, ok = field.Tag.Lookup("arg")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		var arg, err = fieldArgument(field, tag)
+		if err == nil {
+			err = RegisterErr(arg)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		fields = append(fields, i)
+		args = append(args, arg)
+	}
+
+	for i, arg := range args {
+		if err := setField(v.Field(fields[i]), arg); err != nil {
+			return fmt.Errorf("%s: %w", v.Type().Field(fields[i]).Name, err)
+		}
+	}
+
+	return nil
+}
+
+// fieldArgument returns the Argument for a struct field from its tags.
+func fieldArgument(field reflect.StructField, tag string) (arg Argument, err error) {
+	var options = strings.Split(tag, ",")
+	arg.Name = options[0]
+	if arg.Name == "" {
+		arg.Name = strings.ToLower(field.Name)
+	}
+	for _, option := range options[1:] {
+		var key, value, _ = strings.Cut(option, "=")
+		switch key {
+		case "short":
+			arg.Short = value
+		default:
+			return arg, fmt.Errorf("--%s has unknown option \"%s\"", arg.Name, option)
+		}
+	}
+	arg.DefaultValue = field.Tag.Get("default")
+	arg.Description = field.Tag.Get("description")
+
+	switch {
+	case field.Type == durationType:
+		arg.ExpectsValue = true
+		arg.Type = TypeDuration
+	case field.Type.Kind() == reflect.String:
+		arg.ExpectsValue = true
+	case field.Type.Kind() == reflect.Int:
+		arg.ExpectsValue = true
+		arg.Type = TypeInt
+	case field.Type.Kind() == reflect.Float64:
+		arg.ExpectsValue = true
+		arg.Type = TypeFloat
+	case field.Type.Kind() == reflect.Bool:
+		arg.Negatable = arg.DefaultValue != ""
+	case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+		arg.ExpectsValue = true
+		arg.Multiple = true
+	default:
+		return arg, fmt.Errorf("--%s has unsupported type %s", arg.Name, field.Type)
+	}
+
+	return arg, nil
+}
+
+// setField sets a struct field to the value of arg.
+func setField(field reflect.Value, arg Argument) error {
+	switch {
+	case field.Type() == durationType:
+		var d, err = Duration(arg.Name)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(Value(arg.Name))
+	case field.Kind() == reflect.Int:
+		var i, err = Int(arg.Name)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case field.Kind() == reflect.Float64:
+		var f, err = Float(arg.Name)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case field.Kind() == reflect.Bool:
+		field.SetBool(Bool(arg.Name))
+	case field.Kind() == reflect.Slice:
+		field.Set(reflect.ValueOf(Values(arg.Name)))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type intoOptions struct {
+	Output   string        `arg:"output,short=o" description:"Output file"`
+	Port     int           `arg:"port" default:"8080"`
+	Ratio    float64       `arg:"ratio"`
+	Verbose  bool          `arg:"verbose,short=v"`
+	Color    bool          `arg:"color" default:"true"`
+	Timeout  time.Duration `arg:"timeout" default:"5s"`
+	Include  []string      `arg:"include"`
+	Untagged string
+}
+
+func TestParseInto(t *testing.T) {
+	setArgs(t, "-o", "file.txt", "--ratio=0.5", "-v", "--no-color", "--include=a", "--include=b")
+
+	var options intoOptions
+	if err := ParseInto(&options); err != nil {
+		t.Fatal(err)
+	}
+	var expected = intoOptions{
+		Output:  "file.txt",
+		Port:    8080,
+		Ratio:   0.5,
+		Verbose: true,
+		Color:   false,
+		Timeout: 5 * time.Second,
+		Include: []string{"a", "b"},
+	}
+	if fmt.Sprint(options) != fmt.Sprint(expected) {
+		t.Errorf("expected %+v, got %+v", expected, options)
+	}
+	if arg, found := lookupArg("output"); !found || arg.Short != "o" || arg.Description != "Output file" {
+		t.Errorf("expected --output to be registered from its tags, got %+v", arg)
+	}
+}
+
+func TestParseIntoSliceDefault(t *testing.T) {
+	setArgs(t)
+	var options struct {
+		Include []string `arg:"include" default:"x"`
+	}
+	if err := ParseInto(&options); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(options.Include) != "[x]" {
+		t.Errorf("expected the default to be the only element of Include, got %v", options.Include)
+	}
+}
+
+func TestParseIntoErrors(t *testing.T) {
+	setArgs(t, "--port=abc")
+	var options intoOptions
+	if err := ParseInto(&options); err == nil || err.Error() != "Port: --port expects an integer value: strconv.Atoi: parsing \"abc\": invalid syntax" {
+		t.Errorf("expected a conversion error for Port, got %v", err)
+	}
+
+	setArgs(t)
+	if err := ParseInto(options); err == nil {
+		t.Error("expected an error for a struct that is not a pointer")
+	}
+
+	setArgs(t)
+	var unsupported struct {
+		Values map[string]string `arg:"values"`
+	}
+	if err := ParseInto(&unsupported); err == nil || err.Error() != "Values: --values has unsupported type map[string]string" {
+		t.Errorf("expected an unsupported type error, got %v", err)
+	}
+}
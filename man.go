@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManPage generates a man page in the given section (e.g. 1) for progName based on the arguments you have registered.
+func ManPage(progName string, section string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var page strings.Builder
+	fmt.Fprintf(&page, ".TH %s %s\n", manEscape(strings.ToUpper(progName)), section)
+
+	page.WriteString(".SH NAME\n")
+	page.WriteString(manEscape(progName) + "\n")
+
+	page.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&page, ".B %s\n", manEscape(progName))
+	if CustomUsage != "" {
+		page.WriteString(manEscape(CustomUsage) + "\n")
+	}
+	if flags := availableFlags(); flags != "" {
+		page.WriteString("[" + manEscape(flags) + "]\n")
+	}
+
+	var args = usageArgs()
+	if len(args) != 0 {
+		page.WriteString(".SH OPTIONS\n")
+	}
+	for _, arg := range args {
+		page.WriteString(".TP\n")
+
+		var flags []string
+		if arg.Short != "" {
+			flags = append(flags, `\fB\-`+manEscape(arg.Short)+`\fR`)
+		}
+		if arg.Name != "" {
+			flags = append(flags, `\fB\-\-`+manEscape(arg.Name)+`\fR`)
+		}
+		if arg.ExpectsValue {
+			flags[len(flags)-1] += `=\fI` + manEscape(strings.ToUpper(arg.key())) + `\fR`
+		}
+		page.WriteString(strings.Join(flags, ", ") + "\n")
+
+		if arg.Description != "" {
+			page.WriteString(manEscape(arg.Description) + "\n")
+		}
+		if len(arg.Values) != 0 {
+			page.WriteString(".br\nValues: " + manEscape(strings.Join(arg.Values, ", ")) + "\n")
+		}
+		if defaultValue := arg.defaultValue(); defaultValue != "" {
+			page.WriteString(".br\nDefault: " + manEscape(defaultValue) + "\n")
+		}
+	}
+
+	return page.String()
+}
+
+// manEscape escapes s to be used as text in a man page.
+func manEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+
+	return s
+}
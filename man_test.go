@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestManPage(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "format", Short: "f", Description: "Output format", ExpectsValue: true, Values: []string{"json", "xml"}, DefaultValue: "json"})
+	Register(Argument{Name: "dry-run", Description: "Do not write files"})
+
+	var page = ManPage("mytool", "1")
+	for _, expected := range []string{
+		".TH MYTOOL 1\n",
+		".SH NAME\nmytool\n",
+		".SH SYNOPSIS\n.B mytool\n[\\-f= \\-\\-dry\\-run]\n",
+		".SH OPTIONS\n",
+		".TP\n\\fB\\-f\\fR, \\fB\\-\\-format\\fR=\\fIFORMAT\\fR\nOutput format\n.br\nValues: json, xml\n.br\nDefault: json\n",
+		".TP\n\\fB\\-\\-dry\\-run\\fR\nDo not write files\n",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected man page to contain %q, got:\n%s", expected, page)
+		}
+	}
+}
+
+func TestManPageHidden(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "debug-trace", Description: "Trace internals", Hidden: true})
+
+	if page := ManPage("mytool", "1"); strings.Contains(page, ".SH OPTIONS") || strings.Contains(page, "debug") {
+		t.Errorf("expected no options section when every argument is hidden, got:\n%s", page)
+	}
+}
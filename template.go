@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+// DefaultUsageTemplate is a template for PrintUsageTo that renders the same usage message as PrintUsage.
+const DefaultUsageTemplate = `{{.Strings.Usage}}: {{.Program}} {{.CustomUsage}} [{{.Flags}}]
+{{with .Description}}{{.}}
+
+{{end}}{{with .Header}}{{.}}
+
+{{end}}{{.Commands}}{{.Options}}{{.Examples}}{{with .Footer}}
+{{.}}
+{{end}}`
+
+// UsageData is the data that the template passed to PrintUsageTo is executed with.
+type UsageData struct {
+	// Program is the name of the binary.
+	Program string
+	// CustomUsage, Header and Footer are CustomUsage, UsageHeader and UsageFooter.
+	CustomUsage string
+	Header      string
+	Footer      string
+	// Description is the ProgramDescription, wrapped to the width of the terminal.
+	Description string
+	// Flags are the flags that could be used in a single line (e.g. -a= --verbose).
+	Flags string
+	// Commands, Options and Examples are the sections of the usage message printed by PrintUsage.
+	Commands string
+	Options  string
+	Examples string
+	// Arguments are the registered arguments in the order they are printed in the usage message.
+	Arguments []Argument
+	// Strings are the labels printed in the usage message (see Strings).
+	Strings UsageStrings
+}
+
+// PrintUsageTo writes a usage message to w rendered by tmpl, a text/template executed with UsageData.
+// In addition to the functions built into text/template, tmpl can use flags to get the flags of an Argument
+// as they are printed in the usage message (e.g. -a= --arg=), details to get its description, values, default value, etc.,
+// and join to join strings with a separator (e.g. strings.Join).
+func PrintUsageTo(w io.Writer, tmpl string) error {
+	var t, err = template.New("usage").Funcs(template.FuncMap{
+		"flags": func(arg Argument) string {
+			if arg.Short == "" || arg.Name == "" {
+				return usageFlags(arg, 0)
+			}
+			return usageShort(arg) + " " + usageFlags(arg, 0)
+		},
+		"details": usageDetails,
+		"join":    strings.Join,
+	}).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, usageData())
+}
+
+// usageData returns the UsageData for the arguments and usage you have registered.
+func usageData() UsageData {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var description string
+	if ProgramDescription != "" {
+		description = strings.Join(wrap(ProgramDescription, usageWidth()), "\n")
+	}
+
+	return UsageData{
+		Program:     programName(),
+		CustomUsage: CustomUsage,
+		Header:      UsageHeader,
+		Footer:      UsageFooter,
+		Description: description,
+		Flags:       availableFlags(),
+		Commands:    commandsUsage(),
+		Options:     optionsUsage(false),
+		Examples:    examplesUsage(),
+		Arguments:   usageArgs(),
+		Strings:     Strings,
+	}
+}
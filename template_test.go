@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintUsageTo(t *testing.T) {
+	setArgs(t)
+	Register(Argument{Name: "output", Short: "o", Description: "Output file", ExpectsValue: true})
+	Register(Argument{Name: "verbose", Group: "Logging"})
+	Register(Argument{Name: "debug", Hidden: true})
+
+	var buf bytes.Buffer
+	if err := PrintUsageTo(&buf, "{{range .Arguments}}{{flags .}}\n{{end}}"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "-o= --output=\n--verbose\n" {
+		t.Errorf("expected only flags, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintUsageTo(&buf, `{{range .Arguments}}{{.Name}}: {{details .}}{{"\n"}}{{end}}{{join (split .Flags) ", "}}`); err == nil {
+		t.Error("expected an error for a function that does not exist")
+	}
+
+	buf.Reset()
+	if err := PrintUsageTo(&buf, `{{range .Arguments}}{{.Name}}: {{details .}}{{"\n"}}{{end}}{{join .Arguments ", "}}`); err == nil {
+		t.Error("expected an error for join with arguments that are not strings")
+	}
+
+	if err := PrintUsageTo(&buf, "{{"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestDefaultUsageTemplate(t *testing.T) {
+	ProgramDescription = "Builds things."
+	UsageHeader = "Header"
+	UsageFooter = "Footer"
+	t.Cleanup(func() {
+		ProgramDescription = ""
+		UsageHeader = ""
+		UsageFooter = ""
+	})
+
+	setArgs(t)
+	Command("build").Description = "Build a binary"
+	Register(Argument{Name: "output", Short: "o", Description: "Output file", ExpectsValue: true, DefaultValue: "a.out"})
+	Register(Argument{Name: "verbose", Group: "Logging"})
+	Example("mytool build", "Build a binary")
+
+	for _, set := range []func(){func() {}, func() { ProgramDescription, UsageHeader, UsageFooter = "", "", "" }, Reset} {
+		set()
+		var buf bytes.Buffer
+		if err := PrintUsageTo(&buf, DefaultUsageTemplate); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != usage() {
+			t.Errorf("expected the default template to render:\n%s\ngot:\n%s", usage(), buf.String())
+		}
+	}
+}
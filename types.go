@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import "fmt"
+
+// Type is the type of the value of an Argument, checked by Validate.
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeDuration
+)
+
+// String returns the name of the Type as it is printed in the usage message (e.g. int).
+func (t Type) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeDuration:
+		return "duration"
+	}
+
+	return "string"
+}
+
+// checkTypes returns an error for each of values of arg that does not parse as its Type.
+func checkTypes(arg Argument, values []string) (errs []error) {
+	for _, value := range values {
+		if err := checkType(arg, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
+
+// typedValue returns value of arg parsed as its Type for DumpJSON (e.g. 80 instead of "80" for a TypeInt Argument),
+// or value as it is if it is empty, it does not parse as its Type, or its Type is TypeString or TypeDuration.
+func typedValue(arg Argument, value string) interface{} {
+	if value == "" {
+		return value
+	}
+	switch arg.Type {
+	case TypeInt:
+		if i, err := parseInt(arg.key(), value); err == nil {
+			return i
+		}
+	case TypeFloat:
+		if f, err := parseFloat(arg.key(), value); err == nil {
+			return f
+		}
+	case TypeBool:
+		if isTruthy(value) || isFalsy(value) {
+			return isTruthy(value)
+		}
+	}
+
+	return value
+}
+
+// checkType returns an error if value of arg does not parse as its Type.
+func checkType(arg Argument, value string) (err error) {
+	switch arg.Type {
+	case TypeInt:
+		_, err = parseInt(arg.key(), value)
+	case TypeFloat:
+		_, err = parseFloat(arg.key(), value)
+	case TypeDuration:
+		_, err = parseDuration(arg.key(), value)
+	case TypeBool:
+		if value != "" && !isTruthy(value) && !isFalsy(value) {
+			err = fmt.Errorf("%s expects a boolean value, got \"%s\"", arg.flag(), value)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2023 Brandon Jordan
+ */
+
+package args
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTypes(t *testing.T) {
+	setArgs(t, "--port=abc", "--ratio=0.5", "--debug=maybe", "--timeout=30")
+	Register(Argument{Name: "port", ExpectsValue: true, Type: TypeInt})
+	Register(Argument{Name: "ratio", ExpectsValue: true, Type: TypeFloat})
+	Register(Argument{Name: "debug", ExpectsValue: true, Type: TypeBool})
+	Register(Argument{Name: "timeout", ExpectsValue: true, Type: TypeDuration})
+	Register(Argument{Name: "retries", ExpectsValue: true, Type: TypeInt})
+
+	var err = Validate()
+	var errs, ok = err.(Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expected 3 type errors, got %v", err)
+	}
+	for i, name := range []string{"--debug", "--port", "--timeout"} {
+		if !strings.HasPrefix(errs[i].Error(), name) {
+			t.Errorf("expected error for %s, got %s", name, errs[i])
+		}
+	}
+
+	Parse([]string{"--port=8080", "--debug=yes", "--timeout=30s"})
+	if err = Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if usage := usage(); !strings.Contains(usage, "--port=<int>") || !strings.Contains(usage, "--timeout=<duration>") {
+		t.Errorf("expected usage to show types, got:\n%s", usage)
+	}
+
+	setArgs(t, "--n=abc", "--n=2", "--n=x")
+	Register(Argument{Name: "n", ExpectsValue: true, Multiple: true, Type: TypeInt})
+	if errs, ok := Validate().(Errors); !ok || len(errs) != 2 || !strings.Contains(errs[0].Error(), "abc") || !strings.Contains(errs[1].Error(), "\"x\"") {
+		t.Errorf("expected every value of a multiple argument to be type checked, got %v", errs)
+	}
+}